@@ -4,10 +4,12 @@ import (
 	"fmt"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/mattn/go-runewidth"
 
 	"github.com/BoburF/terminal-web.git/internal/page"
 )
@@ -19,6 +21,7 @@ type Box struct {
 	context     []any
 	IsPageLink  bool
 	PageTarget  string
+	listItems   map[int]string // index into texts -> "ul" or "ol" for list items
 }
 
 type Controller struct {
@@ -32,6 +35,8 @@ type State struct {
 	Height              int
 	boxes               []Box
 	interactivity       []Controller
+	script              *luaScript
+	renderer            *lipgloss.Renderer // styles for the session's terminal, nil for the local one
 	quitting            bool
 	session             any
 	currentSection      int
@@ -40,14 +45,48 @@ type State struct {
 	sectionTitles       []string
 	pages               []page.PageInfo
 	currentPageIdx      int
-	pageHistory         []int
+	pageHistory         []historyEntry
+	pageForward         []historyEntry
+	expandedGroups      map[string]bool // groups opened in the sidebar page tree
+	selectedEntry       string          // page tree entry ←/→ move through, see visibleTreeEntries
 	showPagePrompt      bool
+	showPageSwitcher    bool
+	switcherQuery       string
+	switcherCursor      int
+	showSearch          bool
+	searchQuery         string
+	searchCursor        int
+	searchIndex         []searchLine
+	findTyping          bool
+	findQuery           string
+	findCurrent         int
+	findSection         int
+	findPageIdx         int
 	pendingPageIdx      int
 	promptMessage       string
 	notSwitchedMsg      string
 	notSwitchedTimer    int
 	pageLinks           []page.PageLink
 	lastTabPressed      bool
+	idleRemaining       time.Duration
+	shutdownNotice      string
+	pageCache           *page.Cache
+	entry               string
+	theme               Theme
+	pageError           string
+	onError             func(error)
+}
+
+// historyEntry is a visited page and where the visitor was on it
+type historyEntry struct {
+	pageIdx  int
+	position page.PagePosition
+}
+
+// idleWarningMsg carries the time left before an idle session is disconnected;
+// a zero value clears the warning
+type idleWarningMsg struct {
+	remaining time.Duration
 }
 
 func (s State) Init() tea.Cmd {
@@ -61,9 +100,46 @@ func (s State) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		s.Height = msg.Height
 		return s, nil
 
+	case idleWarningMsg:
+		s.idleRemaining = msg.remaining
+		return s, nil
+
+	case contentChangedMsg:
+		return s.reloadContent(), nil
+
+	case shutdownNoticeMsg:
+		s.shutdownNotice = fmt.Sprintf("Server restarting - this session will close within %v", msg.drain)
+		return s, nil
+
 	case tea.KeyMsg:
+		s.idleRemaining = 0
+
 		keyStr := msg.String()
 
+		if s.pageError != "" {
+			if keyStr == "ctrl+c" || keyStr == "q" {
+				s.quitting = true
+				return s, tea.Quit
+			}
+			// Any other key returns to the page that was showing, if there is one
+			if len(s.boxes) > 0 {
+				s.pageError = ""
+			}
+			return s, nil
+		}
+
+		if s.showPageSwitcher {
+			return s.updatePageSwitcher(msg)
+		}
+
+		if s.showSearch {
+			return s.updateSearch(msg)
+		}
+
+		if s.findTyping {
+			return s.updateFind(msg)
+		}
+
 		if len(keyStr) == 1 && keyStr >= "0" && keyStr <= "9" {
 			s.pendingSectionNum += keyStr
 			return s, nil
@@ -71,6 +147,13 @@ func (s State) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 		if keyStr == "esc" {
 			s.pendingSectionNum = ""
+			if s.selectedEntry != "" && !s.showPagePrompt {
+				s.selectedEntry = ""
+				return s, nil
+			}
+			if s.findQuery != "" && !s.showPagePrompt {
+				return s.clearFind(), nil
+			}
 			if s.showPagePrompt {
 				s.showPagePrompt = false
 				s.notSwitchedMsg = "Not switched"
@@ -108,12 +191,17 @@ func (s State) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return s, nil
 		}
 
+		if keyStr == "enter" && s.selectedEntry != "" {
+			s.lastTabPressed = false
+			return s.openTreeSelection()
+		}
+
 		if keyStr == "enter" && s.lastTabPressed {
 			s.lastTabPressed = false
 			if s.currentSection >= 0 && s.currentSection < len(s.pageLinks) {
 				link := s.pageLinks[s.currentSection]
 				if link.Target != "" {
-					pageIdx := s.findPageIndex(link.Target)
+					pageIdx := s.findPageTarget(link.Target)
 					if pageIdx >= 0 {
 						s.pendingPageIdx = pageIdx
 						s.promptMessage = link.SectionTitle
@@ -124,6 +212,14 @@ func (s State) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return s, nil
 		}
 
+		if s.findQuery != "" && (keyStr == "n" || keyStr == "N") {
+			s.lastTabPressed = false
+			if keyStr == "n" {
+				return s.stepFind(1), nil
+			}
+			return s.stepFind(-1), nil
+		}
+
 		switch keyStr {
 		case "tab":
 			if s.pendingSectionNum != "" {
@@ -144,6 +240,7 @@ func (s State) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				s.sectionScrollOffset = 0
 			}
 			s.lastTabPressed = true
+			s.selectedEntry = ""
 			return s, nil
 		case "shift+tab":
 			s.currentSection--
@@ -153,6 +250,7 @@ func (s State) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			s.sectionScrollOffset = 0
 			s.pendingSectionNum = ""
 			s.lastTabPressed = false
+			s.selectedEntry = ""
 			return s, nil
 		case "j", "down":
 			s.lastTabPressed = false
@@ -175,6 +273,18 @@ func (s State) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 			s.pendingSectionNum = ""
 			return s, nil
+		case "right":
+			s.lastTabPressed = false
+			return s.expandGroup(), nil
+		case "left":
+			s.lastTabPressed = false
+			return s.collapseGroup(), nil
+		case "p":
+			return s.openPageSwitcher(), nil
+		case "/":
+			return s.openSearch(), nil
+		case "?":
+			return s.openFind(), nil
 		case "ctrl+c", "q":
 			s.quitting = true
 			return s, tea.Quit
@@ -185,6 +295,13 @@ func (s State) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				return s.handleController(ctrl)
 			}
 		}
+
+		if cmd, ok, err := s.script.run(keyStr); ok {
+			if err != nil {
+				return s.showError(err), nil
+			}
+			return s, cmd
+		}
 	}
 
 	return s, nil
@@ -204,6 +321,8 @@ func (s State) handleController(ctrl Controller) (tea.Model, tea.Cmd) {
 		return s, nil
 	case "back":
 		return s.navigateBack()
+	case "forward":
+		return s.navigateForward()
 	default:
 		return s, nil
 	}
@@ -211,25 +330,18 @@ func (s State) handleController(ctrl Controller) (tea.Model, tea.Cmd) {
 
 func (s State) confirmPageSwitch() (State, tea.Cmd) {
 	if s.pendingPageIdx >= 0 && s.pendingPageIdx < len(s.pages) {
-		pageInfo := s.pages[s.pendingPageIdx]
-
-		body, err := page.LoadPage(pageInfo.Filename)
+		next, err := s.loadPage(s.pendingPageIdx)
 		if err != nil {
 			s.showPagePrompt = false
-			s.notSwitchedMsg = "Error loading page"
-			s.notSwitchedTimer = 3
-			return s, nil
+			s.pendingPageIdx = 0
+			s.promptMessage = ""
+			return s.showError(err), nil
 		}
+		s = next
 
-		boxes, sectionTitles, pageLinks := parseMain(body)
-		controllers := parseControlls(body)
-
-		s.pageHistory = append(s.pageHistory, s.currentPageIdx)
+		s.pageHistory = append(s.pageHistory, s.historyEntry())
+		s.pageForward = nil
 		s.currentPageIdx = s.pendingPageIdx
-		s.boxes = boxes
-		s.sectionTitles = sectionTitles
-		s.pageLinks = pageLinks
-		s.interactivity = controllers
 		s.currentSection = 0
 		s.sectionScrollOffset = 0
 		s.showPagePrompt = false
@@ -240,32 +352,142 @@ func (s State) confirmPageSwitch() (State, tea.Cmd) {
 }
 
 func (s State) navigateBack() (State, tea.Cmd) {
-	if len(s.pageHistory) > 0 {
-		lastIdx := len(s.pageHistory) - 1
-		prevPageIdx := s.pageHistory[lastIdx]
-		s.pageHistory = s.pageHistory[:lastIdx]
-
-		if prevPageIdx >= 0 && prevPageIdx < len(s.pages) {
-			pageInfo := s.pages[prevPageIdx]
-			body, err := page.LoadPage(pageInfo.Filename)
-			if err == nil {
-				boxes, sectionTitles, pageLinks := parseMain(body)
-				controllers := parseControlls(body)
-
-				s.boxes = boxes
-				s.sectionTitles = sectionTitles
-				s.pageLinks = pageLinks
-				s.interactivity = controllers
-			}
-		}
+	if len(s.pageHistory) == 0 {
+		return s, nil
+	}
 
-		s.currentPageIdx = prevPageIdx
-		s.currentSection = 0
-		s.sectionScrollOffset = 0
+	lastIdx := len(s.pageHistory) - 1
+	prev := s.pageHistory[lastIdx]
+	current := s.historyEntry()
+
+	next, err := s.restoreEntry(prev)
+	if err != nil {
+		return s.showError(err), nil
+	}
+	next.pageHistory = s.pageHistory[:lastIdx]
+	next.pageForward = append(s.pageForward, current)
+	return next, nil
+}
+
+func (s State) navigateForward() (State, tea.Cmd) {
+	if len(s.pageForward) == 0 {
+		return s, nil
+	}
+
+	lastIdx := len(s.pageForward) - 1
+	following := s.pageForward[lastIdx]
+	current := s.historyEntry()
+
+	next, err := s.restoreEntry(following)
+	if err != nil {
+		return s.showError(err), nil
+	}
+	next.pageForward = s.pageForward[:lastIdx]
+	next.pageHistory = append(s.pageHistory, current)
+	return next, nil
+}
+
+// historyEntry records the current page and position
+func (s State) historyEntry() historyEntry {
+	return historyEntry{
+		pageIdx: s.currentPageIdx,
+		position: page.PagePosition{
+			SectionIndex: s.currentSection,
+			ScrollOffset: s.sectionScrollOffset,
+			LastVisited:  time.Now().Unix(),
+		},
 	}
+}
+
+// restoreEntry loads the entry's page and returns to its section and
+// scroll offset, clamped in case the page has changed since
+func (s State) restoreEntry(entry historyEntry) (State, error) {
+	if entry.pageIdx < 0 || entry.pageIdx >= len(s.pages) {
+		return s, fmt.Errorf("page %d no longer exists", entry.pageIdx+1)
+	}
+
+	next, err := s.loadPage(entry.pageIdx)
+	if err != nil {
+		return s, err
+	}
+
+	next.currentPageIdx = entry.pageIdx
+	next.currentSection = max(min(entry.position.SectionIndex, len(next.boxes)-1), 0)
+	next.sectionScrollOffset = max(min(entry.position.ScrollOffset, next.getMaxScrollOffset()), 0)
+	return next, nil
+}
+
+// loadPage parses the page at pageIdx into the state's sections and
+// controllers, leaving the state untouched when the page is broken
+func (s State) loadPage(pageIdx int) (State, error) {
+	pageInfo := s.pages[pageIdx]
+
+	loaded, err := s.pageCache.Load(pageInfo.Filename)
+	if err != nil {
+		return s, fmt.Errorf("failed to load %s: %w", pageInfo.Filename, err)
+	}
+	body := loaded.Body()
+
+	boxes, sectionTitles, pageLinks, err := parseMain(body)
+	if err != nil {
+		return s, fmt.Errorf("%s: %w", pageInfo.Filename, err)
+	}
+	controllers, err := parseControlls(body)
+	if err != nil {
+		return s, fmt.Errorf("%s: %w", pageInfo.Filename, err)
+	}
+
+	s.boxes = boxes
+	s.sectionTitles = sectionTitles
+	s.pageLinks = pageLinks
+	s.interactivity = controllers
 	return s, nil
 }
 
+// showError replaces the view with an error page and reports err through onError
+func (s State) showError(err error) State {
+	s.pageError = err.Error()
+	if s.onError != nil {
+		s.onError(err)
+	}
+	return s
+}
+
+func (s State) renderErrorPage() string {
+	width := 60
+	if s.Width-4 < width {
+		width = s.Width - 4
+	}
+
+	titleStyle := s.newStyle().
+		Foreground(lipgloss.Color(s.theme.Danger)).
+		Bold(true)
+
+	descStyle := s.newStyle().
+		Foreground(lipgloss.Color(s.theme.Muted)).
+		Width(width - 4)
+
+	keyStyle := s.newStyle().
+		Foreground(lipgloss.Color(s.theme.Binding)).
+		Bold(true)
+
+	content := titleStyle.Render("This page could not be displayed") + "\n\n"
+	content += descStyle.Render(s.pageError) + "\n\n"
+	if len(s.boxes) > 0 {
+		content += keyStyle.Render("[any key] Back  ")
+	}
+	content += keyStyle.Render("[q] Quit")
+
+	box := s.newStyle().
+		Border(lipgloss.NormalBorder()).
+		BorderForeground(lipgloss.Color(s.theme.Danger)).
+		Padding(1).
+		Width(width).
+		Render(content)
+
+	return lipgloss.Place(s.Width, s.Height, lipgloss.Center, lipgloss.Center, box)
+}
+
 func (s State) checkPageLinkSection() {
 	if len(s.pageLinks) == 0 {
 		return
@@ -274,12 +496,24 @@ func (s State) checkPageLinkSection() {
 		link := s.pageLinks[s.currentSection]
 		if link.Target != "" {
 			s.showPagePrompt = true
-			s.pendingPageIdx = s.findPageIndex(link.Target)
+			s.pendingPageIdx = s.findPageTarget(link.Target)
 			s.promptMessage = link.SectionTitle
 		}
 	}
 }
 
+// findPageTarget finds the page a page-target on the current page points at
+func (s State) findPageTarget(target string) int {
+	return s.findPageIndex(page.ResolveTarget(s.currentFilename(), target))
+}
+
+func (s State) currentFilename() string {
+	if s.currentPageIdx >= 0 && s.currentPageIdx < len(s.pages) {
+		return s.pages[s.currentPageIdx].Filename
+	}
+	return ""
+}
+
 func (s State) findPageIndex(filename string) int {
 	for i, p := range s.pages {
 		if p.Filename == filename {
@@ -303,30 +537,30 @@ func (s State) renderPagePrompt() string {
 		y = 1
 	}
 
-	promptStyle := lipgloss.NewStyle().
+	promptStyle := s.newStyle().
 		Border(lipgloss.NormalBorder()).
 		Width(promptWidth).
 		Height(promptHeight).
 		AlignHorizontal(lipgloss.Center).
 		AlignVertical(lipgloss.Center)
 
-	titleStyle := lipgloss.NewStyle().
-		Foreground(lipgloss.Color("#3B82F6")).
+	titleStyle := s.newStyle().
+		Foreground(lipgloss.Color(s.theme.Accent)).
 		Bold(true).
 		Width(promptWidth - 4).
 		AlignHorizontal(lipgloss.Center)
 
-	descStyle := lipgloss.NewStyle().
-		Foreground(lipgloss.Color("#9CA3AF")).
+	descStyle := s.newStyle().
+		Foreground(lipgloss.Color(s.theme.Muted)).
 		Width(promptWidth - 4).
 		AlignHorizontal(lipgloss.Center)
 
-	keyStyle := lipgloss.NewStyle().
-		Foreground(lipgloss.Color("#10B981")).
+	keyStyle := s.newStyle().
+		Foreground(lipgloss.Color(s.theme.Success)).
 		Bold(true)
 
-	skipKeyStyle := lipgloss.NewStyle().
-		Foreground(lipgloss.Color("#EF4444")).
+	skipKeyStyle := s.newStyle().
+		Foreground(lipgloss.Color(s.theme.Danger)).
 		Bold(true)
 
 	pageTitle := s.promptMessage
@@ -376,15 +610,35 @@ func (s State) getMaxScrollOffset() int {
 	return s.getSectionHeight(s.currentSection) - s.getContentHeight()
 }
 
+// newStyle starts a style in the color profile of the visitor's terminal
+func (s State) newStyle() lipgloss.Style {
+	if s.renderer != nil {
+		return s.renderer.NewStyle()
+	}
+	return lipgloss.NewStyle()
+}
+
 func (s State) View() string {
 	if s.quitting {
 		return ""
 	}
 
+	if s.pageError != "" {
+		return s.renderErrorPage()
+	}
+
 	if s.showPagePrompt {
 		return s.renderPagePrompt()
 	}
 
+	if s.showPageSwitcher {
+		return s.renderPageSwitcher()
+	}
+
+	if s.showSearch {
+		return s.renderSearch()
+	}
+
 	if s.Width < 20 || s.Height < 10 {
 		return "Terminal too small"
 	}
@@ -406,7 +660,7 @@ func (s State) View() string {
 		contentWidth = 10
 	}
 
-	boxStyle := lipgloss.NewStyle().
+	boxStyle := s.newStyle().
 		Border(lipgloss.NormalBorder()).
 		Padding(1).
 		Width(contentWidth).
@@ -414,26 +668,32 @@ func (s State) View() string {
 		AlignHorizontal(lipgloss.Left).
 		AlignVertical(lipgloss.Top)
 
-	indicatorStyle := lipgloss.NewStyle().
-		Foreground(lipgloss.Color("#3B82F6")).
+	indicatorStyle := s.newStyle().
+		Foreground(lipgloss.Color(s.theme.Accent)).
 		Bold(true).
 		Width(s.Width - 2).
 		AlignHorizontal(lipgloss.Center)
 
-	controllerStyle := lipgloss.NewStyle().
-		Foreground(lipgloss.Color("#059669")).
+	controllerStyle := s.newStyle().
+		Foreground(lipgloss.Color(s.theme.Text)).
 		Bold(true)
 
-	bindingStyle := lipgloss.NewStyle().
-		Foreground(lipgloss.Color("#7C3AED")).
+	bindingStyle := s.newStyle().
+		Foreground(lipgloss.Color(s.theme.Binding)).
 		Bold(true)
 
-	pendingStyle := lipgloss.NewStyle().
-		Foreground(lipgloss.Color("#F59E0B")).
+	pendingStyle := s.newStyle().
+		Foreground(lipgloss.Color(s.theme.Highlight)).
 		Bold(true)
 
+	warningStyle := s.newStyle().
+		Foreground(lipgloss.Color(s.theme.Danger)).
+		Bold(true).
+		Width(s.Width - 2).
+		AlignHorizontal(lipgloss.Center)
+
 	// Sidebar styles
-	sidebarStyle := lipgloss.NewStyle().
+	sidebarStyle := s.newStyle().
 		Border(lipgloss.NormalBorder()).
 		Padding(1).
 		Width(sidebarWidth).
@@ -441,17 +701,20 @@ func (s State) View() string {
 		AlignHorizontal(lipgloss.Left).
 		AlignVertical(lipgloss.Top)
 
-	sidebarHeaderStyle := lipgloss.NewStyle().
-		Foreground(lipgloss.Color("#3B82F6")).
+	sidebarHeaderStyle := s.newStyle().
+		Foreground(lipgloss.Color(s.theme.Accent)).
 		Bold(true).
 		Underline(true)
 
-	sidebarActiveStyle := lipgloss.NewStyle().
-		Foreground(lipgloss.Color("#F59E0B")).
+	sidebarActiveStyle := s.newStyle().
+		Foreground(lipgloss.Color(s.theme.Highlight)).
 		Bold(true)
 
-	sidebarInactiveStyle := lipgloss.NewStyle().
-		Foreground(lipgloss.Color("#059669"))
+	sidebarInactiveStyle := s.newStyle().
+		Foreground(lipgloss.Color(s.theme.Text))
+
+	sidebarGroupStyle := s.newStyle().
+		Foreground(lipgloss.Color(s.theme.Muted))
 
 	box := s.boxes[s.currentSection]
 
@@ -465,6 +728,8 @@ func (s State) View() string {
 		}
 	}
 
+	contentLines = s.highlightFind(contentLines)
+
 	visibleHeight := s.getContentHeight()
 	if len(contentLines) > visibleHeight {
 		endIdx := s.sectionScrollOffset + visibleHeight
@@ -478,10 +743,23 @@ func (s State) View() string {
 	totalSections := len(s.boxes)
 
 	var indicatorText string
-	if s.pendingSectionNum != "" {
+	if s.shutdownNotice != "" {
+		indicatorText = warningStyle.Render(s.shutdownNotice)
+	} else if s.idleRemaining > 0 {
+		seconds := int(s.idleRemaining.Round(time.Second) / time.Second)
+		indicatorText = warningStyle.Render(fmt.Sprintf("Idle: disconnecting in %ds - press any key to stay", seconds))
+	} else if s.findTyping || s.findQuery != "" {
+		indicatorText = pendingStyle.Render(truncateString(s.findStatus(), s.Width-2))
+	} else if s.pendingSectionNum != "" {
 		indicatorText = pendingStyle.Render(fmt.Sprintf("%d/%d: Jumping to section %s...", sectionNum, totalSections, s.pendingSectionNum))
+	} else if s.selectedEntry != "" {
+		indicatorText = pendingStyle.Render(truncateString("Page tree: ←/→ to move, Enter to open, Esc to cancel", s.Width-2))
 	} else {
-		indicatorText = indicatorStyle.Render(fmt.Sprintf("%d/%d: %s - Press Tab to switch", sectionNum, totalSections, getSectionTitle(box)))
+		text := fmt.Sprintf("%d/%d: %s - Press Tab to switch", sectionNum, totalSections, getSectionTitle(box))
+		if len(s.pages) > 1 {
+			text = s.breadcrumbs() + " | " + text
+		}
+		indicatorText = indicatorStyle.Render(truncateString(text, s.Width-2))
 	}
 
 	var controllerParts []string
@@ -518,6 +796,19 @@ func (s State) View() string {
 		}
 	}
 
+	if len(s.pages) > 1 {
+		// The tree gets whatever height the sections leave
+		available := s.getContentHeight() - len(sidebarLines) - 3
+		tree := s.pageTreeLines(maxLabelWidth, sidebarActiveStyle, sidebarInactiveStyle, sidebarGroupStyle)
+		if available > 0 {
+			if len(tree) > available {
+				tree = append(tree[:available-1], sidebarGroupStyle.Render("  ..."))
+			}
+			sidebarLines = append(sidebarLines, "", sidebarHeaderStyle.Render("Pages"), "")
+			sidebarLines = append(sidebarLines, tree...)
+		}
+	}
+
 	// Join sidebar lines and render
 	sidebarContent := strings.Join(sidebarLines, "\n")
 	sidebarRendered := sidebarStyle.Render(sidebarContent)
@@ -542,12 +833,14 @@ func getSectionTitle(box Box) string {
 	return "Section"
 }
 
+// truncateString cuts s down to maxLen terminal columns, never splitting a
+// character, and marks the cut with "..." when there is room for it
 func truncateString(s string, maxLen int) string {
-	if len(s) <= maxLen {
+	if runewidth.StringWidth(s) <= maxLen {
 		return s
 	}
 	if maxLen <= 3 {
-		return s[:maxLen]
+		return runewidth.Truncate(s, max(maxLen, 0), "")
 	}
-	return s[:maxLen-3] + "..."
+	return runewidth.Truncate(s, maxLen, "...")
 }
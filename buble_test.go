@@ -0,0 +1,28 @@
+package main
+
+import "testing"
+
+func TestTruncateString(t *testing.T) {
+	tests := []struct {
+		in     string
+		maxLen int
+		want   string
+	}{
+		{"Portfolio", 20, "Portfolio"},
+		{"Portfolio", 9, "Portfolio"},
+		{"Portfolio", 8, "Portf..."},
+		{"Portfolio", 3, "Por"},
+		{"Portfolio", 0, ""},
+		{"Résumé of Bobur", 8, "Résum..."},
+		{"Тажриба ва лойиҳалар", 10, "Тажриба..."},
+		{"日本語のページ", 9, "日本語..."},
+		{"日本語のページ", 8, "日本..."},
+		{"日本語", 3, "日"},
+	}
+
+	for _, tt := range tests {
+		if got := truncateString(tt.in, tt.maxLen); got != tt.want {
+			t.Errorf("truncateString(%q, %d) = %q, want %q", tt.in, tt.maxLen, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,202 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"html/template"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/BoburF/terminal-web.git/internal/page"
+)
+
+// siteTemplate lays a page out like the TUI: pages across the header,
+// numbered sections in a sidebar and the section contents beside it
+var siteTemplate = template.Must(template.New("page").Funcs(template.FuncMap{
+	"inc": func(i int) int { return i + 1 },
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Page.Title}}</title>
+{{- with .Page.Description}}
+<meta name="description" content="{{.}}">
+{{- end}}
+<style>
+:root {
+    --accent: {{.Theme.Accent}};
+    --highlight: {{.Theme.Highlight}};
+    --text: {{.Theme.Text}};
+    --binding: {{.Theme.Binding}};
+    --muted: {{.Theme.Muted}};
+}
+* { box-sizing: border-box; }
+body { margin: 0; background: #111827; color: #E5E7EB; font: 15px/1.6 Menlo, Consolas, "DejaVu Sans Mono", monospace; }
+a { color: var(--accent); }
+header { display: flex; flex-wrap: wrap; gap: 1.5em; align-items: baseline; padding: 0.8em 1.5em; border-bottom: 1px solid var(--accent); }
+header .counter { color: var(--accent); font-weight: bold; }
+header nav a { margin-right: 1.2em; color: var(--muted); text-decoration: none; }
+header nav a.current { color: var(--highlight); font-weight: bold; }
+.layout { display: flex; gap: 2em; max-width: 1100px; margin: 0 auto; padding: 1.5em; }
+aside { flex: 0 0 240px; position: sticky; top: 1.5em; align-self: flex-start; border: 1px solid var(--accent); padding: 0.8em 1em; }
+aside h2 { margin: 0 0 0.5em; font-size: 1em; color: var(--accent); }
+aside ol { list-style: none; margin: 0; padding: 0; }
+aside a { color: var(--text); text-decoration: none; }
+aside a:hover { color: var(--highlight); }
+aside .number { color: var(--binding); }
+main { flex: 1; min-width: 0; }
+main .description { color: var(--muted); font-style: italic; }
+section { border: 1px solid #374151; padding: 0.5em 1.2em; margin-bottom: 1.5em; }
+section:target { border-color: var(--highlight); }
+section h2 { color: var(--accent); font-size: 1.1em; }
+section p { margin: 0.3em 0; white-space: pre-wrap; }
+section .page-link { color: var(--highlight); }
+@media (max-width: 760px) {
+    .layout { flex-direction: column; }
+    aside { position: static; flex-basis: auto; }
+}
+</style>
+</head>
+<body>
+<header>
+    <span class="counter">{{inc .Index}}/{{len .Pages}}: {{.Page.Title}}</span>
+    <nav>
+    {{- range $i, $p := .Pages}}
+        <a href="{{$.Href $p.Filename}}"{{if eq $i $.Index}} class="current"{{end}}>{{$p.Title}}</a>
+    {{- end}}
+    </nav>
+</header>
+<div class="layout">
+    <aside>
+        <h2>Sections</h2>
+        <ol>
+        {{- range $i, $s := .Page.Sections}}
+            <li><a href="#{{index $.Anchors $i}}"><span class="number">[{{inc $i}}]</span> {{$s.Title}}</a></li>
+        {{- end}}
+        </ol>
+    </aside>
+    <main>
+        <h1>{{.Page.Title}}</h1>
+        {{- with .Page.Description}}
+        <p class="description">{{.}}</p>
+        {{- end}}
+        {{- range $i, $s := .Page.Sections}}
+        <section id="{{index $.Anchors $i}}">
+            <h2>{{if .PageTarget}}<a href="{{$.Href .PageTarget}}">{{.Title}}</a>{{else}}{{.Title}}{{end}}</h2>
+            {{- range .Lines}}
+            <p>{{.}}</p>
+            {{- end}}
+            {{- if .PageTarget}}
+            <p><a class="page-link" href="{{$.Href .PageTarget}}">&rarr; {{$.TitleOf .PageTarget}}</a></p>
+            {{- end}}
+        </section>
+        {{- end}}
+    </main>
+</div>
+</body>
+</html>
+`))
+
+// sitePage is the data rendered by siteTemplate for one page
+type sitePage struct {
+	Page    DocPage
+	Pages   []DocPage
+	Index   int
+	Theme   Theme
+	Anchors []string // id of each section, unique within the page
+}
+
+// Href links from the page being rendered to another page of the site
+func (sp sitePage) Href(filename string) string {
+	return page.RelativeTarget(sp.Page.Filename, filename)
+}
+
+// TitleOf returns the title of the page a page-target points at
+func (sp sitePage) TitleOf(filename string) string {
+	return pageTitle(sp.Pages, filename)
+}
+
+// runBuildSiteCommand handles the "build-site" subcommand
+func runBuildSiteCommand(args []string) {
+	flags := flag.NewFlagSet("build-site", flag.ExitOnError)
+	configPath := flags.String("config", "", "Path to a YAML or TOML configuration file")
+	outDir := flags.String("out", "public", "Output directory")
+	flags.Parse(args)
+
+	config, err := LoadConfig(*configPath)
+	if err != nil {
+		log.Fatalf("Failed to load configuration: %v", err)
+	}
+
+	content := contentFS(config.Content.Root)
+	pages, err := page.DiscoverPages(content)
+	if err != nil {
+		log.Fatalf("Failed to discover pages: %v", err)
+	}
+
+	theme, err := loadTheme(content)
+	if err != nil {
+		log.Printf("Warning: Could not load theme: %v", err)
+	}
+
+	docs, err := loadDocuments(page.NewCache(content), pages)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	files, err := buildSite(docs, theme, *outDir)
+	if err != nil {
+		log.Fatalf("Build failed: %v", err)
+	}
+
+	for _, file := range files {
+		fmt.Println("Wrote", file)
+	}
+}
+
+// buildSite renders every page to outDir under its original filename, so
+// page-target links keep working
+func buildSite(docs []DocPage, theme Theme, outDir string) ([]string, error) {
+	if err := os.MkdirAll(outDir, 0o755); err != nil {
+		return nil, err
+	}
+
+	files := make([]string, 0, len(docs))
+	for i, doc := range docs {
+		path := filepath.Join(outDir, filepath.FromSlash(doc.Filename))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return nil, err
+		}
+		err := writeFile(path, func(file *os.File) error {
+			return siteTemplate.Execute(file, sitePage{Page: doc, Pages: docs, Index: i, Theme: theme, Anchors: sectionAnchors(doc.Sections, make(map[string]bool))})
+		})
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, path)
+	}
+
+	return files, nil
+}
+
+// sectionAnchors returns an id for every section, numbering repeated titles
+// "-2", "-3" and so on so that each id is unique; used holds ids already
+// taken and gains the new ones
+func sectionAnchors(sections []DocSection, used map[string]bool) []string {
+	anchors := make([]string, len(sections))
+	for i, section := range sections {
+		base := markdownAnchor(section.Title)
+		if base == "" {
+			base = "section"
+		}
+		anchor := base
+		for n := 2; used[anchor]; n++ {
+			anchor = fmt.Sprintf("%s-%d", base, n)
+		}
+		used[anchor] = true
+		anchors[i] = anchor
+	}
+	return anchors
+}
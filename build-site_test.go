@@ -0,0 +1,34 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSectionAnchors(t *testing.T) {
+	tests := []struct {
+		name   string
+		titles []string
+		want   []string
+	}{
+		{name: "distinct titles", titles: []string{"About Me", "Work"}, want: []string{"about-me", "work"}},
+		{name: "repeated title", titles: []string{"Projects", "Projects", "Projects"}, want: []string{"projects", "projects-2", "projects-3"}},
+		{name: "same anchor from different titles", titles: []string{"C++", "C"}, want: []string{"c", "c-2"}},
+		{name: "numbered title already taken", titles: []string{"Talks 2", "Talks", "Talks"}, want: []string{"talks-2", "talks", "talks-3"}},
+		{name: "non-ASCII titles", titles: []string{"Опыт", "Ишлар", "Опыт"}, want: []string{"опыт", "ишлар", "опыт-2"}},
+		{name: "title without anchor characters", titles: []string{"C++", "!!"}, want: []string{"c", "section"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sections := make([]DocSection, len(tt.titles))
+			for i, title := range tt.titles {
+				sections[i].Title = title
+			}
+			got := sectionAnchors(sections, make(map[string]bool))
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("sectionAnchors(%q) = %q, want %q", tt.titles, got, tt.want)
+			}
+		})
+	}
+}
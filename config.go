@@ -1,59 +1,308 @@
 package main
 
 import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
 	"time"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
 )
 
+// EnvPrefix is prepended to every environment variable override
+const EnvPrefix = "TERMINAL_WEB_"
+
 // Config holds all server configuration
 type Config struct {
-	Server   ServerConfig
-	Security SecurityConfig
-	Logging  LoggingConfig
+	Server   ServerConfig   `yaml:"server" toml:"server"`
+	Security SecurityConfig `yaml:"security" toml:"security"`
+	Logging  LoggingConfig  `yaml:"logging" toml:"logging"`
+	Content  ContentConfig  `yaml:"content" toml:"content"`
 }
 
 // ServerConfig holds server-specific settings
 type ServerConfig struct {
-	Host    string
-	Port    string
-	HostKey string
+	Host         string        `yaml:"host" toml:"host"`
+	Port         string        `yaml:"port" toml:"port"`
+	HostKeys     []string      `yaml:"host_keys" toml:"host_keys"`
+	DrainTimeout time.Duration `yaml:"drain_timeout" toml:"drain_timeout"`
+	HTTP         string        `yaml:"http" toml:"http"`     // address of the browser terminal, e.g. ":8080"; empty disables it
+	Telnet       string        `yaml:"telnet" toml:"telnet"` // address of the telnet listener, e.g. ":2323"; empty disables it
 }
 
 // SecurityConfig holds security-related settings
 type SecurityConfig struct {
-	MaxConnections     int
-	RateLimitPerMinute int
-	IdleTimeout        time.Duration
-	MaxSessionDuration time.Duration
+	MaxConnections     int           `yaml:"max_connections" toml:"max_connections"`
+	RateLimitPerMinute int           `yaml:"rate_limit_per_minute" toml:"rate_limit_per_minute"`
+	IdleTimeout        time.Duration `yaml:"idle_timeout" toml:"idle_timeout"`
+	IdleWarning        time.Duration `yaml:"idle_warning" toml:"idle_warning"`
+	MaxSessionDuration time.Duration `yaml:"max_session_duration" toml:"max_session_duration"`
 }
 
 // LoggingConfig holds logging settings
 type LoggingConfig struct {
-	Level string
-	File  string
+	Level string `yaml:"level" toml:"level"`
+	File  string `yaml:"file" toml:"file"`
+}
+
+// ContentConfig holds the location of the resume content
+type ContentConfig struct {
+	Root        string `yaml:"root" toml:"root"`   // directory on disk; empty serves the content embedded in the binary
+	Entry       string `yaml:"entry" toml:"entry"` // page shown when a session starts
+	Watch       bool   `yaml:"watch" toml:"watch"`
+	Sites       string `yaml:"sites" toml:"sites"`               // directory of per-username sites; empty serves Root to everyone
+	DefaultSite string `yaml:"default_site" toml:"default_site"` // site under Sites for unknown usernames
 }
 
 // DefaultConfig returns the default configuration matching user requirements:
 // - Port: 4569
+// - Host key: ed25519, generated on first start if missing
 // - Max Connections: 30
 // - Rate Limit: 10/minute per IP
-// - Idle Timeout: 5 minutes
+// - Idle Timeout: 5 minutes (warning shown 30 seconds before disconnect)
 // - Max Session: 10 minutes
+// - Shutdown drain: 30 seconds
 func DefaultConfig() *Config {
 	return &Config{
 		Server: ServerConfig{
-			Host:    "0.0.0.0",
-			Port:    "22",
-			HostKey: "keys/ssh_host_ed25519_key",
+			Host:         "0.0.0.0",
+			Port:         "22",
+			HostKeys:     []string{"keys/ssh_host_ed25519_key"},
+			DrainTimeout: 30 * time.Second,
 		},
 		Security: SecurityConfig{
 			MaxConnections:     30,
 			RateLimitPerMinute: 10,
 			IdleTimeout:        5 * time.Minute,
+			IdleWarning:        30 * time.Second,
 			MaxSessionDuration: 10 * time.Minute,
 		},
 		Logging: LoggingConfig{
 			Level: "info",
 			File:  "logs/terminal-web.log",
 		},
+		Content: ContentConfig{
+			Entry:       "index.html",
+			DefaultSite: "default",
+		},
+	}
+}
+
+// LoadConfig builds the effective configuration: defaults, then the optional
+// config file, then TERMINAL_WEB_* environment overrides
+func LoadConfig(path string) (*Config, error) {
+	config := DefaultConfig()
+
+	if path != "" {
+		if err := config.loadFile(path); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := config.applyEnv(os.LookupEnv); err != nil {
+		return nil, err
+	}
+
+	return config, nil
+}
+
+// loadFile decodes a YAML or TOML file on top of the current values
+func (c *Config) loadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		if err := yaml.Unmarshal(data, c); err != nil {
+			return fmt.Errorf("failed to parse YAML config %s: %w", path, err)
+		}
+	case ".toml":
+		if _, err := toml.Decode(string(data), c); err != nil {
+			return fmt.Errorf("failed to parse TOML config %s: %w", path, err)
+		}
+	default:
+		return fmt.Errorf("unsupported config format %q (use .yaml, .yml or .toml)", filepath.Ext(path))
+	}
+
+	return nil
+}
+
+// envOverride maps one environment variable onto a config field
+type envOverride struct {
+	name  string
+	apply func(c *Config, value string) error
+}
+
+var envOverrides = []envOverride{
+	{"SERVER_HOST", func(c *Config, v string) error { c.Server.Host = v; return nil }},
+	{"SERVER_PORT", func(c *Config, v string) error { c.Server.Port = v; return nil }},
+	{"SERVER_HOST_KEYS", func(c *Config, v string) error { c.Server.HostKeys = splitListEnv(v); return nil }},
+	{"SERVER_HTTP", func(c *Config, v string) error { c.Server.HTTP = v; return nil }},
+	{"SERVER_TELNET", func(c *Config, v string) error { c.Server.Telnet = v; return nil }},
+	{"SERVER_DRAIN_TIMEOUT", func(c *Config, v string) error { return parseDurationEnv(v, &c.Server.DrainTimeout) }},
+	{"SECURITY_MAX_CONNECTIONS", func(c *Config, v string) error { return parseIntEnv(v, &c.Security.MaxConnections) }},
+	{"SECURITY_RATE_LIMIT_PER_MINUTE", func(c *Config, v string) error { return parseIntEnv(v, &c.Security.RateLimitPerMinute) }},
+	{"SECURITY_IDLE_TIMEOUT", func(c *Config, v string) error { return parseDurationEnv(v, &c.Security.IdleTimeout) }},
+	{"SECURITY_IDLE_WARNING", func(c *Config, v string) error { return parseDurationEnv(v, &c.Security.IdleWarning) }},
+	{"SECURITY_MAX_SESSION_DURATION", func(c *Config, v string) error { return parseDurationEnv(v, &c.Security.MaxSessionDuration) }},
+	{"LOGGING_LEVEL", func(c *Config, v string) error { c.Logging.Level = v; return nil }},
+	{"LOGGING_FILE", func(c *Config, v string) error { c.Logging.File = v; return nil }},
+	{"CONTENT_ROOT", func(c *Config, v string) error { c.Content.Root = v; return nil }},
+	{"CONTENT_ENTRY", func(c *Config, v string) error { c.Content.Entry = v; return nil }},
+	{"CONTENT_SITES", func(c *Config, v string) error { c.Content.Sites = v; return nil }},
+	{"CONTENT_DEFAULT_SITE", func(c *Config, v string) error { c.Content.DefaultSite = v; return nil }},
+	{"CONTENT_WATCH", func(c *Config, v string) error { return parseBoolEnv(v, &c.Content.Watch) }},
+}
+
+// applyEnv applies every TERMINAL_WEB_* variable found by lookup
+func (c *Config) applyEnv(lookup func(string) (string, bool)) error {
+	for _, override := range envOverrides {
+		name := EnvPrefix + override.name
+		value, ok := lookup(name)
+		if !ok {
+			continue
+		}
+		if err := override.apply(c, value); err != nil {
+			return fmt.Errorf("invalid %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+func parseIntEnv(value string, target *int) error {
+	n, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil {
+		return fmt.Errorf("expected an integer, got %q", value)
+	}
+	*target = n
+	return nil
+}
+
+func parseDurationEnv(value string, target *time.Duration) error {
+	d, err := time.ParseDuration(strings.TrimSpace(value))
+	if err != nil {
+		return fmt.Errorf("expected a duration like 5m or 30s, got %q", value)
+	}
+	*target = d
+	return nil
+}
+
+func splitListEnv(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+func parseBoolEnv(value string, target *bool) error {
+	b, err := strconv.ParseBool(strings.TrimSpace(value))
+	if err != nil {
+		return fmt.Errorf("expected true or false, got %q", value)
+	}
+	*target = b
+	return nil
+}
+
+// Validate reports every invalid setting at once
+func (c *Config) Validate() error {
+	var errs []error
+
+	if c.Server.Port == "" {
+		errs = append(errs, errors.New("server.port must be set"))
+	} else if port, err := strconv.Atoi(c.Server.Port); err != nil || port < 1 || port > 65535 {
+		errs = append(errs, fmt.Errorf("server.port must be a number between 1 and 65535, got %q", c.Server.Port))
+	}
+	if len(c.Server.HostKeys) == 0 {
+		errs = append(errs, errors.New("server.host_keys must list at least one key file"))
+	}
+	if c.Server.HTTP != "" {
+		if _, _, err := net.SplitHostPort(c.Server.HTTP); err != nil {
+			errs = append(errs, fmt.Errorf("server.http must be a host:port address such as \":8080\", got %q", c.Server.HTTP))
+		}
+	}
+	if c.Server.Telnet != "" {
+		if _, _, err := net.SplitHostPort(c.Server.Telnet); err != nil {
+			errs = append(errs, fmt.Errorf("server.telnet must be a host:port address such as \":2323\", got %q", c.Server.Telnet))
+		}
+	}
+	if c.Server.DrainTimeout < 0 {
+		errs = append(errs, fmt.Errorf("server.drain_timeout must not be negative, got %v", c.Server.DrainTimeout))
+	}
+
+	if c.Security.MaxConnections < 1 {
+		errs = append(errs, fmt.Errorf("security.max_connections must be at least 1, got %d", c.Security.MaxConnections))
+	}
+	if c.Security.RateLimitPerMinute < 1 {
+		errs = append(errs, fmt.Errorf("security.rate_limit_per_minute must be at least 1, got %d", c.Security.RateLimitPerMinute))
+	}
+	if c.Security.IdleTimeout <= 0 {
+		errs = append(errs, fmt.Errorf("security.idle_timeout must be positive, got %v", c.Security.IdleTimeout))
+	}
+	if c.Security.IdleWarning < 0 || c.Security.IdleWarning >= c.Security.IdleTimeout {
+		errs = append(errs, fmt.Errorf("security.idle_warning must be between 0 and idle_timeout (%v), got %v", c.Security.IdleTimeout, c.Security.IdleWarning))
+	}
+	if c.Security.MaxSessionDuration <= 0 {
+		errs = append(errs, fmt.Errorf("security.max_session_duration must be positive, got %v", c.Security.MaxSessionDuration))
+	}
+
+	switch strings.ToLower(c.Logging.Level) {
+	case "debug", "info", "warn", "error":
+	default:
+		errs = append(errs, fmt.Errorf("logging.level must be one of debug, info, warn, error, got %q", c.Logging.Level))
+	}
+	if c.Logging.File == "" {
+		errs = append(errs, errors.New("logging.file must be set"))
+	}
+
+	rootValid := true
+	if c.Content.Root != "" {
+		if info, err := os.Stat(c.Content.Root); err != nil || !info.IsDir() {
+			errs = append(errs, fmt.Errorf("content.root must be an existing directory, got %q", c.Content.Root))
+			rootValid = false
+		}
+	}
+
+	if !strings.HasSuffix(c.Content.Entry, ".html") {
+		errs = append(errs, fmt.Errorf("content.entry must be an .html page, got %q", c.Content.Entry))
+	} else if _, err := fs.Stat(contentFS(c.Content.Root), c.Content.Entry); rootValid && err != nil {
+		errs = append(errs, fmt.Errorf("content.entry %q does not exist in %s", c.Content.Entry, contentLabel(c.Content.Root)))
+	}
+
+	if c.Content.Sites != "" {
+		if info, err := os.Stat(c.Content.Sites); err != nil || !info.IsDir() {
+			errs = append(errs, fmt.Errorf("content.sites must be an existing directory, got %q", c.Content.Sites))
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid configuration:\n%w", errors.Join(errs...))
+	}
+	return nil
+}
+
+// Print writes the configuration in the given format (yaml or toml)
+func (c *Config) Print(w io.Writer, format string) error {
+	switch strings.ToLower(format) {
+	case "yaml", "yml", "":
+		encoder := yaml.NewEncoder(w)
+		encoder.SetIndent(2)
+		defer encoder.Close()
+		return encoder.Encode(c)
+	case "toml":
+		return toml.NewEncoder(w).Encode(c)
+	default:
+		return fmt.Errorf("unsupported output format %q (use yaml or toml)", format)
 	}
 }
@@ -0,0 +1,311 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeConfigFile(t *testing.T, name, data string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadConfigPrecedence(t *testing.T) {
+	yamlFile := `
+server:
+  port: "2222"
+  drain_timeout: 10s
+security:
+  max_connections: 5
+  idle_timeout: 2m
+`
+	tomlFile := `
+[server]
+port = "2222"
+drain_timeout = "10s"
+
+[security]
+max_connections = 5
+idle_timeout = "2m"
+`
+
+	tests := []struct {
+		name            string
+		file            string
+		data            string
+		env             map[string]string
+		wantPort        string
+		wantMaxConns    int
+		wantIdle        time.Duration
+		wantDrain       time.Duration
+		wantRateLimit   int
+		wantHostKeys    []string
+		wantContentRoot string
+	}{
+		{
+			name:          "defaults",
+			wantPort:      "22",
+			wantMaxConns:  30,
+			wantIdle:      5 * time.Minute,
+			wantDrain:     30 * time.Second,
+			wantRateLimit: 10,
+			wantHostKeys:  []string{"keys/ssh_host_ed25519_key"},
+		},
+		{
+			name:          "yaml file over defaults",
+			file:          "config.yaml",
+			data:          yamlFile,
+			wantPort:      "2222",
+			wantMaxConns:  5,
+			wantIdle:      2 * time.Minute,
+			wantDrain:     10 * time.Second,
+			wantRateLimit: 10,
+			wantHostKeys:  []string{"keys/ssh_host_ed25519_key"},
+		},
+		{
+			name:          "toml file over defaults",
+			file:          "config.toml",
+			data:          tomlFile,
+			wantPort:      "2222",
+			wantMaxConns:  5,
+			wantIdle:      2 * time.Minute,
+			wantDrain:     10 * time.Second,
+			wantRateLimit: 10,
+			wantHostKeys:  []string{"keys/ssh_host_ed25519_key"},
+		},
+		{
+			name: "environment over file",
+			file: "config.yml",
+			data: yamlFile,
+			env: map[string]string{
+				"TERMINAL_WEB_SERVER_PORT":                    "2300",
+				"TERMINAL_WEB_SECURITY_IDLE_TIMEOUT":          "90s",
+				"TERMINAL_WEB_SECURITY_RATE_LIMIT_PER_MINUTE": " 3 ",
+				"TERMINAL_WEB_SERVER_HOST_KEYS":               "a, b,,c",
+				"TERMINAL_WEB_CONTENT_ROOT":                   "site",
+			},
+			wantPort:        "2300",
+			wantMaxConns:    5,
+			wantIdle:        90 * time.Second,
+			wantDrain:       10 * time.Second,
+			wantRateLimit:   3,
+			wantHostKeys:    []string{"a", "b", "c"},
+			wantContentRoot: "site",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for name, value := range tt.env {
+				t.Setenv(name, value)
+			}
+			path := ""
+			if tt.file != "" {
+				path = writeConfigFile(t, tt.file, tt.data)
+			}
+
+			config, err := LoadConfig(path)
+			if err != nil {
+				t.Fatalf("LoadConfig: %v", err)
+			}
+
+			if config.Server.Port != tt.wantPort {
+				t.Errorf("port = %q, want %q", config.Server.Port, tt.wantPort)
+			}
+			if config.Security.MaxConnections != tt.wantMaxConns {
+				t.Errorf("max connections = %d, want %d", config.Security.MaxConnections, tt.wantMaxConns)
+			}
+			if config.Security.IdleTimeout != tt.wantIdle {
+				t.Errorf("idle timeout = %v, want %v", config.Security.IdleTimeout, tt.wantIdle)
+			}
+			if config.Server.DrainTimeout != tt.wantDrain {
+				t.Errorf("drain timeout = %v, want %v", config.Server.DrainTimeout, tt.wantDrain)
+			}
+			if config.Security.RateLimitPerMinute != tt.wantRateLimit {
+				t.Errorf("rate limit = %d, want %d", config.Security.RateLimitPerMinute, tt.wantRateLimit)
+			}
+			if strings.Join(config.Server.HostKeys, ",") != strings.Join(tt.wantHostKeys, ",") {
+				t.Errorf("host keys = %q, want %q", config.Server.HostKeys, tt.wantHostKeys)
+			}
+			if config.Content.Root != tt.wantContentRoot {
+				t.Errorf("content root = %q, want %q", config.Content.Root, tt.wantContentRoot)
+			}
+		})
+	}
+}
+
+func TestLoadConfigErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		data    string
+		env     map[string]string
+		wantErr string
+	}{
+		{
+			name:    "unsupported extension",
+			file:    "config.json",
+			data:    "{}",
+			wantErr: "unsupported config format",
+		},
+		{
+			name:    "broken yaml",
+			file:    "config.yaml",
+			data:    "server: [",
+			wantErr: "failed to parse YAML config",
+		},
+		{
+			name:    "broken toml",
+			file:    "config.toml",
+			data:    "[server",
+			wantErr: "failed to parse TOML config",
+		},
+		{
+			name:    "invalid integer override",
+			env:     map[string]string{"TERMINAL_WEB_SECURITY_MAX_CONNECTIONS": "many"},
+			wantErr: "invalid TERMINAL_WEB_SECURITY_MAX_CONNECTIONS",
+		},
+		{
+			name:    "invalid duration override",
+			env:     map[string]string{"TERMINAL_WEB_SECURITY_IDLE_TIMEOUT": "5"},
+			wantErr: "expected a duration",
+		},
+		{
+			name:    "invalid bool override",
+			env:     map[string]string{"TERMINAL_WEB_CONTENT_WATCH": "sometimes"},
+			wantErr: "expected true or false",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for name, value := range tt.env {
+				t.Setenv(name, value)
+			}
+			path := ""
+			if tt.file != "" {
+				path = writeConfigFile(t, tt.file, tt.data)
+			}
+
+			_, err := LoadConfig(path)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("LoadConfig error = %v, want it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+
+	if _, err := LoadConfig(filepath.Join(t.TempDir(), "missing.yaml")); err == nil {
+		t.Error("LoadConfig of a missing file succeeded")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	contentDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(contentDir, "index.html"), []byte("<html></html>"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		modify   func(c *Config)
+		wantErrs []string
+	}{
+		{
+			name:   "defaults with embedded content",
+			modify: func(c *Config) {},
+		},
+		{
+			name:   "content root on disk",
+			modify: func(c *Config) { c.Content.Root = contentDir },
+		},
+		{
+			name:     "port out of range",
+			modify:   func(c *Config) { c.Server.Port = "70000" },
+			wantErrs: []string{"server.port must be a number"},
+		},
+		{
+			name:     "missing port",
+			modify:   func(c *Config) { c.Server.Port = "" },
+			wantErrs: []string{"server.port must be set"},
+		},
+		{
+			name:     "no host keys",
+			modify:   func(c *Config) { c.Server.HostKeys = nil },
+			wantErrs: []string{"server.host_keys"},
+		},
+		{
+			name:     "bad listener addresses",
+			modify:   func(c *Config) { c.Server.HTTP = "8080"; c.Server.Telnet = "telnet" },
+			wantErrs: []string{"server.http", "server.telnet"},
+		},
+		{
+			name: "limits",
+			modify: func(c *Config) {
+				c.Security.MaxConnections = 0
+				c.Security.RateLimitPerMinute = 0
+				c.Security.MaxSessionDuration = 0
+			},
+			wantErrs: []string{"security.max_connections", "security.rate_limit_per_minute", "security.max_session_duration"},
+		},
+		{
+			name:     "idle warning not below idle timeout",
+			modify:   func(c *Config) { c.Security.IdleWarning = c.Security.IdleTimeout },
+			wantErrs: []string{"security.idle_warning"},
+		},
+		{
+			name:     "unknown log level",
+			modify:   func(c *Config) { c.Logging.Level = "verbose" },
+			wantErrs: []string{"logging.level"},
+		},
+		{
+			name:     "missing content root",
+			modify:   func(c *Config) { c.Content.Root = filepath.Join(contentDir, "missing") },
+			wantErrs: []string{"content.root"},
+		},
+		{
+			name:     "entry that is not a page",
+			modify:   func(c *Config) { c.Content.Entry = "index.md" },
+			wantErrs: []string{"content.entry must be an .html page"},
+		},
+		{
+			name:     "entry missing from root",
+			modify:   func(c *Config) { c.Content.Root = contentDir; c.Content.Entry = "about.html" },
+			wantErrs: []string{`content.entry "about.html" does not exist`},
+		},
+		{
+			name:     "missing sites directory",
+			modify:   func(c *Config) { c.Content.Sites = filepath.Join(contentDir, "sites") },
+			wantErrs: []string{"content.sites"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := DefaultConfig()
+			tt.modify(config)
+
+			err := config.Validate()
+			if len(tt.wantErrs) == 0 {
+				if err != nil {
+					t.Fatalf("Validate: %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("Validate succeeded, want errors %q", tt.wantErrs)
+			}
+			for _, want := range tt.wantErrs {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("Validate error %q does not mention %q", err, want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"embed"
+	"io/fs"
+	"os"
+)
+
+// defaultContentDir is the source of the embedded resume, used by commands
+// that need files on disk when no content root is configured
+const defaultContentDir = "./resume/"
+
+// embeddedContent is the resume built into the binary, served when no
+// content directory is configured
+//
+//go:embed resume
+var embeddedContent embed.FS
+
+// contentFS returns the content under root on disk, or the embedded resume
+// when root is empty
+func contentFS(root string) fs.FS {
+	if root == "" {
+		content, err := fs.Sub(embeddedContent, "resume")
+		if err != nil {
+			panic(err) // "resume" is a valid path, Sub cannot fail
+		}
+		return content
+	}
+	return os.DirFS(root)
+}
+
+// contentLabel names root in log and error messages
+func contentLabel(root string) string {
+	if root == "" {
+		return "embedded content"
+	}
+	return root
+}
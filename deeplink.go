@@ -0,0 +1,96 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// DeepLink identifies a page and optional section to open on startup,
+// written as "page#Section" (e.g. "portfolio#Mobile Apps")
+type DeepLink struct {
+	Page    string
+	Section string
+}
+
+// parseDeepLink builds a DeepLink from SSH command arguments
+func parseDeepLink(args []string) (DeepLink, bool) {
+	raw := strings.TrimSpace(strings.Join(args, " "))
+	if raw == "" {
+		return DeepLink{}, false
+	}
+
+	pageRef, section, _ := strings.Cut(raw, "#")
+	return DeepLink{
+		Page:    strings.TrimSpace(pageRef),
+		Section: strings.TrimSpace(section),
+	}, true
+}
+
+// openDeepLink switches to the linked page and section. Unknown pages or
+// sections leave the state where it was and are returned as an error.
+func (s State) openDeepLink(link DeepLink) (State, error) {
+	if link.Page != "" {
+		pageIdx := s.findPageByRef(link.Page)
+		if pageIdx < 0 {
+			return s, fmt.Errorf("unknown page %q", link.Page)
+		}
+		if pageIdx != s.currentPageIdx {
+			s.pendingPageIdx = pageIdx
+			s, _ = s.confirmPageSwitch()
+			if s.pageError != "" {
+				return s, fmt.Errorf("page %q could not be displayed", link.Page)
+			}
+		}
+	}
+
+	if link.Section != "" {
+		sectionIdx := s.findSectionByRef(link.Section)
+		if sectionIdx < 0 {
+			return s, fmt.Errorf("unknown section %q", link.Section)
+		}
+		s.currentSection = sectionIdx
+		s.sectionScrollOffset = 0
+	}
+
+	return s, nil
+}
+
+// findPageByRef matches a page by filename (with or without .html) or title
+func (s State) findPageByRef(ref string) int {
+	filename := ref
+	if !strings.HasSuffix(filename, ".html") {
+		filename += ".html"
+	}
+
+	for i, p := range s.pages {
+		if strings.EqualFold(p.Filename, filename) || strings.EqualFold(p.Title, ref) {
+			return i
+		}
+	}
+	return -1
+}
+
+// findSectionByRef matches a section by 1-based number or title, preferring
+// exact titles over prefixes
+func (s State) findSectionByRef(ref string) int {
+	if n, err := strconv.Atoi(ref); err == nil {
+		if n >= 1 && n <= len(s.boxes) {
+			return n - 1
+		}
+		return -1
+	}
+
+	lowerRef := strings.ToLower(ref)
+	prefixMatch := -1
+	for i, title := range s.sectionTitles {
+		lowerTitle := strings.ToLower(title)
+		if lowerTitle == lowerRef {
+			return i
+		}
+		if prefixMatch < 0 && strings.HasPrefix(lowerTitle, lowerRef) {
+			prefixMatch = i
+		}
+	}
+	return prefixMatch
+}
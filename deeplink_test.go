@@ -0,0 +1,115 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/BoburF/terminal-web.git/internal/page"
+)
+
+func TestParseDeepLink(t *testing.T) {
+	tests := []struct {
+		name   string
+		args   []string
+		want   DeepLink
+		wantOK bool
+	}{
+		{name: "no command", args: nil},
+		{name: "blank command", args: []string{"  "}},
+		{name: "page only", args: []string{"portfolio"}, want: DeepLink{Page: "portfolio"}, wantOK: true},
+		{name: "page and section", args: []string{"portfolio#Mobile"}, want: DeepLink{Page: "portfolio", Section: "Mobile"}, wantOK: true},
+		{name: "section only", args: []string{"#Experience"}, want: DeepLink{Section: "Experience"}, wantOK: true},
+		{name: "split over arguments", args: []string{"portfolio#Mobile", "Apps"}, want: DeepLink{Page: "portfolio", Section: "Mobile Apps"}, wantOK: true},
+		{name: "spaces around parts", args: []string{" index.html ", "# ", "3 "}, want: DeepLink{Page: "index.html", Section: "3"}, wantOK: true},
+		{name: "only the first hash splits", args: []string{"a#b#c"}, want: DeepLink{Page: "a", Section: "b#c"}, wantOK: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := parseDeepLink(tt.args)
+			if ok != tt.wantOK || got != tt.want {
+				t.Errorf("parseDeepLink(%q) = %+v, %v; want %+v, %v", tt.args, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestDeepLinkRefs(t *testing.T) {
+	state := State{
+		pages: []page.PageInfo{
+			{Filename: "index.html", Title: "Index"},
+			{Filename: "portfolio.html", Title: "My Work"},
+			{Filename: "projects/alpha.html", Title: "Alpha"},
+		},
+		boxes:         make([]Box, 3),
+		sectionTitles: []string{"Contact", "Experience", "Experience Details"},
+	}
+
+	pageTests := []struct {
+		ref  string
+		want int
+	}{
+		{"index", 0},
+		{"index.html", 0},
+		{"PORTFOLIO", 1},
+		{"my work", 1},
+		{"projects/alpha", 2},
+		{"alpha.html", -1},
+		{"missing", -1},
+	}
+	for _, tt := range pageTests {
+		if got := state.findPageByRef(tt.ref); got != tt.want {
+			t.Errorf("findPageByRef(%q) = %d, want %d", tt.ref, got, tt.want)
+		}
+	}
+
+	sectionTests := []struct {
+		ref  string
+		want int
+	}{
+		{"1", 0},
+		{"3", 2},
+		{"0", -1},
+		{"4", -1},
+		{"experience", 1},
+		{"Experience D", 2},
+		{"con", 0},
+		{"Education", -1},
+	}
+	for _, tt := range sectionTests {
+		if got := state.findSectionByRef(tt.ref); got != tt.want {
+			t.Errorf("findSectionByRef(%q) = %d, want %d", tt.ref, got, tt.want)
+		}
+	}
+}
+
+func TestOpenDeepLinkErrors(t *testing.T) {
+	state := State{
+		pages:         []page.PageInfo{{Filename: "index.html", Title: "Index"}},
+		boxes:         make([]Box, 2),
+		sectionTitles: []string{"Contact", "About"},
+	}
+
+	tests := []struct {
+		name        string
+		link        DeepLink
+		wantErr     bool
+		wantSection int
+	}{
+		{name: "current page and section", link: DeepLink{Page: "index", Section: "about"}, wantSection: 1},
+		{name: "section only", link: DeepLink{Section: "2"}, wantSection: 1},
+		{name: "unknown page", link: DeepLink{Page: "blog", Section: "about"}, wantErr: true},
+		{name: "unknown section", link: DeepLink{Page: "index", Section: "Skills"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := state.openDeepLink(tt.link)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("openDeepLink(%+v) error = %v, want error %v", tt.link, err, tt.wantErr)
+			}
+			if got.currentSection != tt.wantSection {
+				t.Errorf("openDeepLink(%+v) section = %d, want %d", tt.link, got.currentSection, tt.wantSection)
+			}
+		})
+	}
+}
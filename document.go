@@ -0,0 +1,80 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/BoburF/terminal-web.git/internal/page"
+)
+
+// DocPage is a parsed page in a renderer-friendly form, used by the
+// non-interactive output modes
+type DocPage struct {
+	Filename    string       `json:"filename"`
+	Title       string       `json:"title"`
+	Description string       `json:"description,omitempty"`
+	Sections    []DocSection `json:"sections"`
+}
+
+// DocSection is one section of a page with its text lines in order
+type DocSection struct {
+	Title      string   `json:"title"`
+	Lines      []string `json:"lines"`
+	PageTarget string   `json:"page_target,omitempty"`
+
+	listItems map[int]string // index into Lines -> "ul" or "ol" for list items
+}
+
+// loadDocuments parses every page with the TUI parser
+func loadDocuments(cache *page.Cache, pages []page.PageInfo) ([]DocPage, error) {
+	docs := make([]DocPage, 0, len(pages))
+
+	for _, info := range pages {
+		loaded, err := cache.Load(info.Filename)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load %s: %w", info.Filename, err)
+		}
+		body := loaded.Body()
+
+		boxes, sectionTitles, _, err := parseMain(body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", info.Filename, err)
+		}
+
+		doc := DocPage{
+			Filename:    info.Filename,
+			Title:       info.Title,
+			Description: info.Description,
+			Sections:    make([]DocSection, 0, len(boxes)),
+		}
+		for i, box := range boxes {
+			section := DocSection{
+				Title:      sectionTitles[i],
+				Lines:      box.texts,
+				PageTarget: resolvePageTarget(info.Filename, box.PageTarget),
+				listItems:  box.listItems,
+			}
+			doc.Sections = append(doc.Sections, section)
+		}
+		docs = append(docs, doc)
+	}
+
+	return docs, nil
+}
+
+// pageTitle returns the title of the page with the given filename
+func pageTitle(docs []DocPage, filename string) string {
+	for _, doc := range docs {
+		if doc.Filename == filename {
+			return doc.Title
+		}
+	}
+	return filename
+}
+
+// resolvePageTarget makes a page-target relative to the content root
+func resolvePageTarget(from, target string) string {
+	if target == "" {
+		return ""
+	}
+	return page.ResolveTarget(from, target)
+}
@@ -0,0 +1,237 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Output formats for the non-interactive modes
+const (
+	FormatText     = "text"
+	FormatMarkdown = "markdown"
+	FormatJSON     = "json"
+	FormatHTML     = "html"
+	FormatPDF      = "pdf"
+)
+
+// dumpFormatForCommand maps an SSH command to an output format. Sessions
+// without a PTY always get a dump, defaulting to plain text.
+func dumpFormatForCommand(command []string, isPty bool) (string, bool) {
+	name := ""
+	if len(command) == 1 {
+		name = strings.ToLower(command[0])
+	}
+
+	switch name {
+	case "text", "txt", "cat":
+		return FormatText, true
+	case "md", "markdown":
+		return FormatMarkdown, true
+	case "json":
+		return FormatJSON, true
+	}
+
+	if !isPty {
+		return FormatText, true
+	}
+	return "", false
+}
+
+// writeDocuments renders docs as a single combined document
+func writeDocuments(w io.Writer, docs []DocPage, format string) error {
+	dw := docWriter{all: docs, link: func(target string) string {
+		return "#" + markdownAnchor(pageTitle(docs, target))
+	}}
+	return dw.write(w, docs, format)
+}
+
+// docWriter renders pages, resolving page-target links with link
+type docWriter struct {
+	all  []DocPage // every page, for looking up link titles
+	link func(target string) string
+}
+
+func (dw docWriter) write(w io.Writer, pages []DocPage, format string) error {
+	switch format {
+	case FormatText:
+		return dw.writeText(w, pages)
+	case FormatMarkdown:
+		return dw.writeMarkdown(w, pages)
+	case FormatHTML:
+		return dw.writeHTML(w, pages)
+	case FormatPDF:
+		return dw.writePDF(w, pages)
+	case FormatJSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(struct {
+			Pages []DocPage `json:"pages"`
+		}{pages})
+	default:
+		return fmt.Errorf("unsupported format %q", format)
+	}
+}
+
+func (dw docWriter) writeText(w io.Writer, pages []DocPage) error {
+	var buf bytes.Buffer
+
+	for i, doc := range pages {
+		if i > 0 {
+			buf.WriteString("\n\n")
+		}
+		buf.WriteString(doc.Title + "\n")
+		buf.WriteString(strings.Repeat("=", lipgloss.Width(doc.Title)) + "\n")
+		if doc.Description != "" {
+			buf.WriteString(doc.Description + "\n")
+		}
+
+		for j, section := range doc.Sections {
+			heading := fmt.Sprintf("[%d] %s", j+1, section.Title)
+			buf.WriteString("\n" + heading + "\n")
+			buf.WriteString(strings.Repeat("-", lipgloss.Width(heading)) + "\n")
+			for _, line := range section.Lines {
+				buf.WriteString(line + "\n")
+			}
+			if section.PageTarget != "" {
+				buf.WriteString(fmt.Sprintf("-> See page: %s\n", pageTitle(dw.all, section.PageTarget)))
+			}
+		}
+	}
+
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+func (dw docWriter) writeMarkdown(w io.Writer, pages []DocPage) error {
+	var buf bytes.Buffer
+
+	for i, doc := range pages {
+		if i > 0 {
+			buf.WriteString("\n")
+		}
+		buf.WriteString("# " + markdownEscape(doc.Title) + "\n\n")
+		if doc.Description != "" {
+			buf.WriteString("_" + markdownEscape(doc.Description) + "_\n\n")
+		}
+
+		for _, section := range doc.Sections {
+			buf.WriteString("## " + markdownEscape(section.Title) + "\n\n")
+			for i, line := range section.Lines {
+				buf.WriteString(markdownLine(line, section.listItems[i]) + "\n\n")
+			}
+			if section.PageTarget != "" {
+				buf.WriteString(fmt.Sprintf("See [%s](%s)\n\n", markdownEscape(pageTitle(dw.all, section.PageTarget)), dw.link(section.PageTarget)))
+			}
+		}
+	}
+
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+func (dw docWriter) writeHTML(w io.Writer, pages []DocPage) error {
+	var buf bytes.Buffer
+	esc := html.EscapeString
+
+	title := "Resume"
+	if len(pages) > 0 {
+		title = pages[0].Title
+	}
+
+	buf.WriteString("<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n")
+	buf.WriteString("<title>" + esc(title) + "</title>\n</head>\n<body>\n")
+
+	// Page ids are link targets, so sections are numbered around them
+	used := make(map[string]bool, len(pages))
+	for _, doc := range pages {
+		used[markdownAnchor(doc.Title)] = true
+	}
+
+	for _, doc := range pages {
+		buf.WriteString(fmt.Sprintf("<article id=\"%s\">\n<h1>%s</h1>\n", markdownAnchor(doc.Title), esc(doc.Title)))
+		if doc.Description != "" {
+			buf.WriteString("<p><em>" + esc(doc.Description) + "</em></p>\n")
+		}
+		anchors := sectionAnchors(doc.Sections, used)
+		for i, section := range doc.Sections {
+			buf.WriteString(fmt.Sprintf("<section id=\"%s\">\n<h2>%s</h2>\n", anchors[i], esc(section.Title)))
+			for _, line := range section.Lines {
+				buf.WriteString("<p>" + esc(line) + "</p>\n")
+			}
+			if section.PageTarget != "" {
+				buf.WriteString(fmt.Sprintf("<p><a href=\"%s\">%s</a></p>\n", esc(dw.link(section.PageTarget)), esc(pageTitle(dw.all, section.PageTarget))))
+			}
+			buf.WriteString("</section>\n")
+		}
+		buf.WriteString("</article>\n")
+	}
+	buf.WriteString("</body>\n</html>\n")
+
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// markdownAnchor converts a heading into the anchor GitHub-style renderers generate
+func markdownAnchor(heading string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(heading) {
+		switch {
+		case r == ' ' || r == '-':
+			b.WriteRune('-')
+		case r == '_', unicode.IsLetter(r), unicode.IsDigit(r):
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// markdownEscaper backslash-escapes characters Markdown would format
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`, "`", "\\`", `*`, `\*`, `_`, `\_`, `#`, `\#`, `[`, `\[`, `]`, `\]`,
+	`<`, `\<`, `>`, `\>`, `|`, `\|`, `~`, `\~`,
+)
+
+// markdownBlockPattern finds text that would start a list if it began a line
+var markdownBlockPattern = regexp.MustCompile(`^([-+]|\d+[.)])`)
+
+// markdownEscape makes text render literally in Markdown
+func markdownEscape(text string) string {
+	text = markdownEscaper.Replace(text)
+	if loc := markdownBlockPattern.FindStringIndex(text); loc != nil {
+		text = text[:loc[1]-1] + `\` + text[loc[1]-1:]
+	}
+	return text
+}
+
+// markdownLine escapes one section line, keeping the marker of list items.
+// list is "ul" or "ol" for list items and empty for everything else.
+func markdownLine(line, list string) string {
+	switch list {
+	case "ul":
+		return ListBullet + markdownEscape(strings.TrimPrefix(line, ListBullet))
+	case "ol":
+		number, text, _ := strings.Cut(line, " ")
+		return number + " " + markdownEscape(text)
+	}
+	return markdownEscape(line)
+}
+
+// crlfWriter converts LF to CRLF for terminals without output post-processing
+type crlfWriter struct {
+	w io.Writer
+}
+
+func (cw crlfWriter) Write(p []byte) (int, error) {
+	if _, err := cw.w.Write(bytes.ReplaceAll(p, []byte("\n"), []byte("\r\n"))); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
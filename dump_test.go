@@ -0,0 +1,66 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestMarkdownLine(t *testing.T) {
+	tests := []struct {
+		line string
+		list string
+		want string
+	}{
+		{line: "plain text", want: "plain text"},
+		{line: "*bold* and _em_", want: `\*bold\* and \_em\_`},
+		{line: "# not a heading", want: `\# not a heading`},
+		{line: "[link](url) <b>", want: `\[link\](url) \<b\>`},
+		{line: "- not a list", want: `\- not a list`},
+		{line: "2024. A year", want: `2024\. A year`},
+		{line: ListBullet + "C_sharp", list: "ul", want: ListBullet + `C\_sharp`},
+		{line: "1. first *step*", list: "ol", want: `1. first \*step\*`},
+	}
+
+	for _, tt := range tests {
+		if got := markdownLine(tt.line, tt.list); got != tt.want {
+			t.Errorf("markdownLine(%q, %q) = %q, want %q", tt.line, tt.list, got, tt.want)
+		}
+	}
+}
+
+func TestMarkdownAnchor(t *testing.T) {
+	tests := []struct {
+		heading string
+		want    string
+	}{
+		{"About Me", "about-me"},
+		{"C++ & Go", "c--go"},
+		{"snake_case", "snake_case"},
+		{"Тажриба 2024", "тажриба-2024"},
+	}
+
+	for _, tt := range tests {
+		if got := markdownAnchor(tt.heading); got != tt.want {
+			t.Errorf("markdownAnchor(%q) = %q, want %q", tt.heading, got, tt.want)
+		}
+	}
+}
+
+func TestWriteHTMLUniqueIDs(t *testing.T) {
+	docs := []DocPage{
+		{Title: "Projects", Sections: []DocSection{{Title: "About"}, {Title: "Projects"}}},
+		{Title: "Talks", Sections: []DocSection{{Title: "About"}}},
+	}
+
+	var buf bytes.Buffer
+	if err := writeDocuments(&buf, docs, FormatHTML); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, id := range []string{"projects", "about", "projects-2", "talks", "about-2"} {
+		if count := strings.Count(buf.String(), `id="`+id+`"`); count != 1 {
+			t.Errorf("id %q appears %d times, want once", id, count)
+		}
+	}
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"embed"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/go-pdf/fpdf"
+)
+
+// PDF layout, in millimetres and points
+const (
+	pdfMargin      = 20.0
+	pdfLineHeight  = 5.5
+	pdfListIndent  = 6.0
+	pdfTitleSize   = 20.0
+	pdfHeadingSize = 13.0
+	pdfBodySize    = 10.5
+)
+
+// pdfFonts are UTF-8 fonts; the PDF core fonts only cover Windows-1252
+//
+//go:embed fonts/*.ttf
+var pdfFonts embed.FS
+
+// pdfFont is the family pdfFonts are registered as
+const pdfFont = "DejaVu"
+
+// pdfFontFiles maps fpdf styles to their files in pdfFonts
+var pdfFontFiles = map[string]string{
+	"":  "fonts/DejaVuSansCondensed.ttf",
+	"B": "fonts/DejaVuSansCondensed-Bold.ttf",
+	"I": "fonts/DejaVuSansCondensed-Oblique.ttf",
+}
+
+// pdfURLPattern finds web links and e-mail addresses to make clickable
+var pdfURLPattern = regexp.MustCompile(`(https?://|www\.)[^\s]+|[\w.+-]+@[\w-]+\.[\w.]+`)
+
+// writePDF lays out pages as a printable A4 document. Page links jump within
+// the PDF when the target page is included, otherwise they use dw.link.
+func (dw docWriter) writePDF(w io.Writer, pages []DocPage) error {
+	pdf := fpdf.New("P", "mm", "A4", "")
+	pdf.SetMargins(pdfMargin, pdfMargin, pdfMargin)
+	pdf.SetAutoPageBreak(true, pdfMargin)
+	for style, file := range pdfFontFiles {
+		data, err := pdfFonts.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		pdf.AddUTF8FontFromBytes(pdfFont, style, data)
+	}
+
+	if len(pages) > 0 {
+		pdf.SetTitle(pages[0].Title, true)
+	}
+	pdf.SetFooterFunc(func() {
+		pdf.SetY(-pdfMargin + 5)
+		pdf.SetFont(pdfFont, "", 8)
+		pdf.SetTextColor(156, 163, 175)
+		pdf.CellFormat(0, 5, fmt.Sprintf("%d", pdf.PageNo()), "", 0, "C", false, 0, "")
+	})
+
+	// Reserve an internal link per included page before rendering
+	links := make(map[string]int, len(pages))
+	for _, doc := range pages {
+		links[doc.Filename] = pdf.AddLink()
+	}
+
+	for _, doc := range pages {
+		pdf.AddPage()
+		pdf.SetLink(links[doc.Filename], 0, -1)
+
+		pdf.SetFont(pdfFont, "B", pdfTitleSize)
+		pdf.SetTextColor(17, 24, 39)
+		pdf.MultiCell(0, 9, doc.Title, "", "L", false)
+		if doc.Description != "" {
+			pdf.SetFont(pdfFont, "I", pdfBodySize)
+			pdf.SetTextColor(107, 114, 128)
+			pdf.MultiCell(0, pdfLineHeight, doc.Description, "", "L", false)
+		}
+		pdf.Ln(4)
+
+		for _, section := range doc.Sections {
+			dw.writePDFSection(pdf, links, section)
+		}
+	}
+
+	if err := pdf.Error(); err != nil {
+		return err
+	}
+	return pdf.Output(w)
+}
+
+func (dw docWriter) writePDFSection(pdf *fpdf.Fpdf, links map[string]int, section DocSection) {
+	// Keep a heading together with at least its first lines
+	_, pageHeight := pdf.GetPageSize()
+	if pdf.GetY() > pageHeight-pdfMargin-25 {
+		pdf.AddPage()
+	}
+
+	pdf.Ln(3)
+	pdf.SetFont(pdfFont, "B", pdfHeadingSize)
+	pdf.SetTextColor(59, 130, 246)
+	pdf.MultiCell(0, 7, section.Title, "", "L", false)
+	pageWidth, _ := pdf.GetPageSize()
+	y := pdf.GetY()
+	pdf.SetDrawColor(209, 213, 219)
+	pdf.Line(pdfMargin, y, pageWidth-pdfMargin, y)
+	pdf.Ln(2)
+
+	pdf.SetFont(pdfFont, "", pdfBodySize)
+	pdf.SetTextColor(31, 41, 55)
+	for i, line := range section.Lines {
+		writePDFLine(pdf, line, section.listItems[i])
+	}
+
+	if section.PageTarget != "" {
+		title := pageTitle(dw.all, section.PageTarget)
+		pdf.SetTextColor(37, 99, 235)
+		pdf.SetFont(pdfFont, "U", pdfBodySize)
+		if id, ok := links[section.PageTarget]; ok {
+			pdf.WriteLinkID(pdfLineHeight, "See "+title, id)
+		} else {
+			pdf.WriteLinkString(pdfLineHeight, "See "+title, dw.link(section.PageTarget))
+		}
+		pdf.Ln(pdfLineHeight)
+		pdf.SetFont(pdfFont, "", pdfBodySize)
+		pdf.SetTextColor(31, 41, 55)
+	}
+}
+
+// writePDFLine writes one text line, indenting list items and linking URLs.
+// list is "ul" or "ol" for list items and empty for everything else.
+func writePDFLine(pdf *fpdf.Fpdf, line, list string) {
+	left, _, _, _ := pdf.GetMargins()
+
+	if list != "" {
+		marker := strings.TrimSpace(ListBullet)
+		text := strings.TrimPrefix(line, ListBullet)
+		if list == "ol" {
+			number, rest, _ := strings.Cut(line, " ")
+			marker, text = number, rest
+		}
+		pdf.SetX(left + pdfListIndent/2)
+		pdf.Write(pdfLineHeight, marker)
+		pdf.SetLeftMargin(left + pdfListIndent)
+		pdf.SetX(left + pdfListIndent)
+		line = text
+		defer pdf.SetLeftMargin(left)
+	}
+
+	last := 0
+	for _, loc := range pdfURLPattern.FindAllStringIndex(line, -1) {
+		pdf.Write(pdfLineHeight, line[last:loc[0]])
+		target := line[loc[0]:loc[1]]
+		switch {
+		case strings.Contains(target, "@") && !strings.Contains(target, "/"):
+			target = "mailto:" + target
+		case strings.HasPrefix(target, "www."):
+			target = "https://" + target
+		}
+		pdf.SetTextColor(37, 99, 235)
+		pdf.WriteLinkString(pdfLineHeight, line[loc[0]:loc[1]], target)
+		pdf.SetTextColor(31, 41, 55)
+		last = loc[1]
+	}
+	pdf.Write(pdfLineHeight, line[last:])
+	pdf.Ln(pdfLineHeight)
+}
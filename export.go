@@ -0,0 +1,120 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/BoburF/terminal-web.git/internal/page"
+)
+
+// exportFormats maps accepted -format names to a format and file extension
+var exportFormats = map[string]struct {
+	format string
+	ext    string
+}{
+	"md":       {FormatMarkdown, ".md"},
+	"markdown": {FormatMarkdown, ".md"},
+	"txt":      {FormatText, ".txt"},
+	"text":     {FormatText, ".txt"},
+	"html":     {FormatHTML, ".html"},
+	"json":     {FormatJSON, ".json"},
+	"pdf":      {FormatPDF, ".pdf"},
+}
+
+// runExportCommand handles the "export" subcommand
+func runExportCommand(args []string) {
+	flags := flag.NewFlagSet("export", flag.ExitOnError)
+	configPath := flags.String("config", "", "Path to a YAML or TOML configuration file")
+	formatName := flags.String("format", "md", "Output format: md, txt, html, json or pdf")
+	outDir := flags.String("out", "export", "Output directory")
+	single := flags.Bool("single", false, "Write one combined file instead of one file per page")
+	flags.Parse(args)
+
+	exportFormat, ok := exportFormats[strings.ToLower(*formatName)]
+	if !ok {
+		log.Fatalf("Unsupported export format %q (use md, txt, html, json or pdf)", *formatName)
+	}
+
+	config, err := LoadConfig(*configPath)
+	if err != nil {
+		log.Fatalf("Failed to load configuration: %v", err)
+	}
+
+	content := contentFS(config.Content.Root)
+	pages, err := page.DiscoverPages(content)
+	if err != nil {
+		log.Fatalf("Failed to discover pages: %v", err)
+	}
+
+	docs, err := loadDocuments(page.NewCache(content), pages)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	files, err := exportDocuments(docs, exportFormat.format, exportFormat.ext, *outDir, *single)
+	if err != nil {
+		log.Fatalf("Export failed: %v", err)
+	}
+
+	for _, file := range files {
+		fmt.Println("Wrote", file)
+	}
+}
+
+// exportDocuments writes docs to outDir, either one file per page (with
+// page links pointing at the sibling files) or a single combined file
+func exportDocuments(docs []DocPage, format, ext, outDir string, single bool) ([]string, error) {
+	if err := os.MkdirAll(outDir, 0o755); err != nil {
+		return nil, err
+	}
+
+	if single {
+		path := filepath.Join(outDir, "resume"+ext)
+		err := writeFile(path, func(file *os.File) error {
+			return writeDocuments(file, docs, format)
+		})
+		if err != nil {
+			return nil, err
+		}
+		return []string{path}, nil
+	}
+
+	files := make([]string, 0, len(docs))
+	for _, doc := range docs {
+		dw := docWriter{all: docs, link: func(target string) string {
+			return page.RelativeTarget(doc.Filename, strings.TrimSuffix(target, ".html")+ext)
+		}}
+
+		path := filepath.Join(outDir, filepath.FromSlash(strings.TrimSuffix(doc.Filename, ".html")+ext))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return nil, err
+		}
+		err := writeFile(path, func(file *os.File) error {
+			return dw.write(file, []DocPage{doc}, format)
+		})
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, path)
+	}
+
+	return files, nil
+}
+
+// writeFile creates path and fills it with write
+func writeFile(path string, write func(file *os.File) error) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	if err := write(file); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return file.Close()
+}
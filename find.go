@@ -0,0 +1,223 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// findMatch is an occurrence of the find pattern in the current section;
+// start and end are byte offsets into the line
+type findMatch struct {
+	line  int
+	start int
+	end   int
+}
+
+// openFind starts typing a pattern to find in the current section
+func (s State) openFind() State {
+	s.findTyping = true
+	s.findQuery = ""
+	s.findCurrent = -1
+	s.pendingSectionNum = ""
+	s.lastTabPressed = false
+	return s
+}
+
+// clearFind drops the pattern and its highlights
+func (s State) clearFind() State {
+	s.findTyping = false
+	s.findQuery = ""
+	s.findCurrent = -1
+	return s
+}
+
+// updateFind handles keys while the pattern is typed, moving to the first
+// match from the top of the view as it changes. Ctrl+C quits.
+func (s State) updateFind(msg tea.KeyMsg) (State, tea.Cmd) {
+	switch msg.String() {
+	case "ctrl+c":
+		s.quitting = true
+		return s, tea.Quit
+	case "esc":
+		return s.clearFind(), nil
+	case "enter":
+		if s.findQuery == "" {
+			return s.clearFind(), nil
+		}
+		s.findTyping = false
+		return s, nil
+	case "backspace":
+		query := []rune(s.findQuery)
+		if len(query) == 0 {
+			return s, nil
+		}
+		s.findQuery = string(query[:len(query)-1])
+	default:
+		switch msg.Type {
+		case tea.KeyRunes:
+			s.findQuery += string(msg.Runes)
+		case tea.KeySpace:
+			s.findQuery += " "
+		default:
+			return s, nil
+		}
+	}
+
+	matches := s.findMatches()
+	for i, match := range matches {
+		if match.line >= s.sectionScrollOffset {
+			return s.showFindMatch(matches, i), nil
+		}
+	}
+	if len(matches) > 0 {
+		return s.showFindMatch(matches, 0), nil
+	}
+	s.findCurrent = -1
+	return s, nil
+}
+
+// stepFind moves to the next match when step is 1 and the previous one when
+// it is -1, wrapping around the section
+func (s State) stepFind(step int) State {
+	matches := s.findMatches()
+	if len(matches) == 0 {
+		return s
+	}
+
+	current := s.currentFind(matches)
+	if current < 0 {
+		// Nothing selected in this section yet: start from the top of the view
+		next := 0
+		for i, match := range matches {
+			if match.line >= s.sectionScrollOffset {
+				next = i
+				break
+			}
+		}
+		if step < 0 {
+			next = (next - 1 + len(matches)) % len(matches)
+		}
+		return s.showFindMatch(matches, next)
+	}
+
+	next := (current + step + len(matches)) % len(matches)
+	return s.showFindMatch(matches, next)
+}
+
+// showFindMatch selects a match, scrolling the section when its line is
+// out of view
+func (s State) showFindMatch(matches []findMatch, idx int) State {
+	s.findCurrent = idx
+	s.findSection = s.currentSection
+	s.findPageIdx = s.currentPageIdx
+
+	line := matches[idx].line
+	if line < s.sectionScrollOffset || line >= s.sectionScrollOffset+s.getContentHeight() {
+		s.sectionScrollOffset = max(min(line, s.getMaxScrollOffset()), 0)
+	}
+	return s
+}
+
+// currentFind returns the selected match, or -1 when the selection belongs
+// to another section
+func (s State) currentFind(matches []findMatch) int {
+	if s.findSection != s.currentSection || s.findPageIdx != s.currentPageIdx {
+		return -1
+	}
+	if s.findCurrent < 0 || s.findCurrent >= len(matches) {
+		return -1
+	}
+	return s.findCurrent
+}
+
+// findMatches returns every occurrence of the pattern in the current
+// section, ignoring case
+func (s State) findMatches() []findMatch {
+	if s.findQuery == "" || s.currentSection < 0 || s.currentSection >= len(s.boxes) {
+		return nil
+	}
+
+	var matches []findMatch
+	for i, line := range sectionLines(s.boxes[s.currentSection]) {
+		offset := 0
+		for offset < len(line) {
+			start, end := indexFold(line[offset:], s.findQuery)
+			if start < 0 {
+				break
+			}
+			matches = append(matches, findMatch{line: i, start: offset + start, end: offset + end})
+			offset += end
+		}
+	}
+	return matches
+}
+
+// highlightFind marks the matches in the section's lines, the selected one
+// standing out from the rest
+func (s State) highlightFind(lines []string) []string {
+	matches := s.findMatches()
+	if len(matches) == 0 {
+		return lines
+	}
+	current := s.currentFind(matches)
+	searched := sectionLines(s.boxes[s.currentSection])
+
+	matchStyle := s.newStyle().
+		Foreground(lipgloss.Color(s.theme.Highlight)).
+		Underline(true)
+
+	currentStyle := s.newStyle().
+		Foreground(lipgloss.Color(s.theme.Highlight)).
+		Reverse(true).
+		Bold(true)
+
+	highlighted := make([]string, len(lines))
+	copy(highlighted, lines)
+
+	var b strings.Builder
+	for i := 0; i < len(matches); {
+		line := matches[i].line
+		// Inputs render differently from their text; leave them alone
+		if line >= len(lines) || line >= len(searched) || lines[line] != searched[line] {
+			i++
+			continue
+		}
+
+		b.Reset()
+		last := 0
+		for ; i < len(matches) && matches[i].line == line; i++ {
+			match := matches[i]
+			style := matchStyle
+			if i == current {
+				style = currentStyle
+			}
+			b.WriteString(lines[line][last:match.start])
+			b.WriteString(style.Render(lines[line][match.start:match.end]))
+			last = match.end
+		}
+		b.WriteString(lines[line][last:])
+		highlighted[line] = b.String()
+	}
+
+	return highlighted
+}
+
+// findStatus describes the find for the indicator line
+func (s State) findStatus() string {
+	if s.findTyping {
+		return fmt.Sprintf("Find: %s_", s.findQuery)
+	}
+
+	matches := s.findMatches()
+	if len(matches) == 0 {
+		return fmt.Sprintf("Find %q: no matches in this section - Esc to clear", s.findQuery)
+	}
+	position := "-"
+	if current := s.currentFind(matches); current >= 0 {
+		position = fmt.Sprint(current + 1)
+	}
+	return fmt.Sprintf("Find %q: %s/%d - n/N to move, Esc to clear", s.findQuery, position, len(matches))
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	gossh "golang.org/x/crypto/ssh"
+)
+
+// rsaHostKeyBits is the size used for generated RSA host keys
+const rsaHostKeyBits = 3072
+
+// loadHostKeys loads every configured host key, generating any that are missing
+func loadHostKeys(paths []string) ([]gossh.Signer, error) {
+	signers := make([]gossh.Signer, 0, len(paths))
+
+	for _, path := range paths {
+		signer, err := loadHostKey(path)
+		if errors.Is(err, fs.ErrNotExist) {
+			signer, err = generateHostKey(path)
+			if err == nil {
+				log.Printf("Generated new %s host key: %s", signer.PublicKey().Type(), path)
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+		signers = append(signers, signer)
+	}
+
+	return signers, nil
+}
+
+// loadHostKey loads the SSH host key from file
+func loadHostKey(path string) (gossh.Signer, error) {
+	keyBytes, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read host key file: %w", err)
+	}
+
+	signer, err := gossh.ParsePrivateKey(keyBytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse host key %s: %w", path, err)
+	}
+
+	return signer, nil
+}
+
+// generateHostKey creates a host key at path, choosing the algorithm from the
+// file name (ssh_host_rsa_key, ssh_host_ecdsa_key, otherwise ed25519)
+func generateHostKey(path string) (gossh.Signer, error) {
+	var key crypto.Signer
+	var err error
+
+	name := filepath.Base(path)
+	switch {
+	case strings.Contains(name, "rsa"):
+		key, err = rsa.GenerateKey(rand.Reader, rsaHostKeyBits)
+	case strings.Contains(name, "ecdsa"):
+		key, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	default:
+		_, key, err = ed25519.GenerateKey(rand.Reader)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate host key: %w", err)
+	}
+
+	block, err := gossh.MarshalPrivateKey(key, "terminal-web-host-key")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode host key: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create host key directory: %w", err)
+	}
+
+	// O_EXCL so a concurrently started server never overwrites a key
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create host key file: %w", err)
+	}
+	err = pem.Encode(file, block)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		// A partial key would fail to parse on every later start
+		os.Remove(path)
+		return nil, fmt.Errorf("failed to write host key: %w", err)
+	}
+
+	signer, err := gossh.NewSignerFromKey(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create signer: %w", err)
+	}
+
+	pub := gossh.MarshalAuthorizedKey(signer.PublicKey())
+	if err := os.WriteFile(path+".pub", pub, 0o644); err != nil {
+		log.Printf("Warning: could not write public key %s.pub: %v", path, err)
+	}
+
+	return signer, nil
+}
+
+// logHostKeyFingerprints prints the fingerprints visitors will be asked to verify
+func logHostKeyFingerprints(signers []gossh.Signer) {
+	for _, signer := range signers {
+		log.Printf("Host key fingerprint: %s %s", signer.PublicKey().Type(), gossh.FingerprintSHA256(signer.PublicKey()))
+	}
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	gossh "golang.org/x/crypto/ssh"
+)
+
+func TestGenerateHostKey(t *testing.T) {
+	tests := []struct {
+		name     string
+		wantType string
+	}{
+		{"ssh_host_ed25519_key", gossh.KeyAlgoED25519},
+		{"ssh_host_ecdsa_key", gossh.KeyAlgoECDSA256},
+		{"ssh_host_rsa_key", gossh.KeyAlgoRSA},
+		{"host_key", gossh.KeyAlgoED25519},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "keys", tt.name)
+
+			signer, err := generateHostKey(path)
+			if err != nil {
+				t.Fatalf("generateHostKey: %v", err)
+			}
+			if got := signer.PublicKey().Type(); got != tt.wantType {
+				t.Errorf("key type = %s, want %s", got, tt.wantType)
+			}
+
+			info, err := os.Stat(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if mode := info.Mode().Perm(); mode != 0o600 {
+				t.Errorf("key file mode = %o, want 600", mode)
+			}
+
+			loaded, err := loadHostKey(path)
+			if err != nil {
+				t.Fatalf("loadHostKey: %v", err)
+			}
+			if !bytes.Equal(loaded.PublicKey().Marshal(), signer.PublicKey().Marshal()) {
+				t.Error("key on disk differs from the returned signer")
+			}
+
+			pub, err := os.ReadFile(path + ".pub")
+			if err != nil {
+				t.Fatal(err)
+			}
+			parsed, _, _, _, err := gossh.ParseAuthorizedKey(pub)
+			if err != nil {
+				t.Fatalf("parse %s.pub: %v", tt.name, err)
+			}
+			if !bytes.Equal(parsed.Marshal(), signer.PublicKey().Marshal()) {
+				t.Errorf("%s.pub holds a different key", tt.name)
+			}
+		})
+	}
+}
+
+func TestGenerateHostKeyKeepsExisting(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ssh_host_ed25519_key")
+	if err := os.WriteFile(path, []byte("existing"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := generateHostKey(path); err == nil {
+		t.Fatal("generateHostKey overwrote an existing key")
+	}
+	if data, _ := os.ReadFile(path); string(data) != "existing" {
+		t.Errorf("key file = %q, want it untouched", data)
+	}
+	if _, err := os.Stat(path + ".pub"); !os.IsNotExist(err) {
+		t.Errorf("wrote %s.pub for a key it did not create", path)
+	}
+}
+
+func TestLoadHostKeysGeneratesOnce(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ssh_host_ed25519_key")
+
+	first, err := loadHostKeys([]string{path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := loadHostKeys([]string{path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(first[0].PublicKey().Marshal(), second[0].PublicKey().Marshal()) {
+		t.Error("second start generated a new key instead of loading the existing one")
+	}
+}
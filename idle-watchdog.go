@@ -0,0 +1,97 @@
+package main
+
+import (
+	"io"
+	"sync"
+	"time"
+)
+
+// idleCheckInterval is how often idle sessions are checked and their warning
+// countdown updated
+var idleCheckInterval = time.Second
+
+// IdleWatchdog tracks session activity and fires once no input has been
+// seen for the configured timeout
+type IdleWatchdog struct {
+	timeout  time.Duration
+	lastSeen time.Time
+	mu       sync.Mutex
+	done     chan struct{}
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+// NewIdleWatchdog creates a watchdog and starts monitoring immediately
+func NewIdleWatchdog(timeout time.Duration) *IdleWatchdog {
+	w := &IdleWatchdog{
+		timeout:  timeout,
+		lastSeen: time.Now(),
+		done:     make(chan struct{}),
+		stop:     make(chan struct{}),
+	}
+	go w.run(idleCheckInterval)
+	return w
+}
+
+// run polls the last activity time until the session goes idle or the watchdog is stopped
+func (w *IdleWatchdog) run(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-w.stop:
+			return
+		case <-ticker.C:
+			if w.Remaining() <= 0 {
+				close(w.done)
+				return
+			}
+		}
+	}
+}
+
+// Touch records activity and resets the idle countdown
+func (w *IdleWatchdog) Touch() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.lastSeen = time.Now()
+}
+
+// Remaining returns the time left before the session is considered idle
+func (w *IdleWatchdog) Remaining() time.Duration {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.timeout - time.Since(w.lastSeen)
+}
+
+// Done returns a channel that is closed once the idle timeout has elapsed
+func (w *IdleWatchdog) Done() <-chan struct{} {
+	return w.done
+}
+
+// Stop halts monitoring without firing Done
+func (w *IdleWatchdog) Stop() {
+	w.stopOnce.Do(func() {
+		close(w.stop)
+	})
+}
+
+// Reader wraps r so that every byte read from it resets the watchdog
+func (w *IdleWatchdog) Reader(r io.Reader) io.Reader {
+	return &activityReader{reader: r, watchdog: w}
+}
+
+// activityReader touches the watchdog whenever input is received
+type activityReader struct {
+	reader   io.Reader
+	watchdog *IdleWatchdog
+}
+
+func (ar *activityReader) Read(p []byte) (int, error) {
+	n, err := ar.reader.Read(p)
+	if n > 0 {
+		ar.watchdog.Touch()
+	}
+	return n, err
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// fastIdleChecks makes watchdogs in a test check every few milliseconds
+func fastIdleChecks(t *testing.T) {
+	t.Helper()
+	interval := idleCheckInterval
+	idleCheckInterval = 5 * time.Millisecond
+	t.Cleanup(func() { idleCheckInterval = interval })
+}
+
+func TestIdleWatchdogExpires(t *testing.T) {
+	fastIdleChecks(t)
+	start := time.Now()
+	idle := NewIdleWatchdog(50 * time.Millisecond)
+	defer idle.Stop()
+
+	select {
+	case <-idle.Done():
+	case <-time.After(time.Second):
+		t.Fatal("watchdog did not fire")
+	}
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+		t.Errorf("fired after %v, before the 50ms timeout", elapsed)
+	}
+	if remaining := idle.Remaining(); remaining > 0 {
+		t.Errorf("Remaining() = %v after firing, want <= 0", remaining)
+	}
+}
+
+func TestIdleWatchdogInputResets(t *testing.T) {
+	fastIdleChecks(t)
+	idle := NewIdleWatchdog(60 * time.Millisecond)
+	defer idle.Stop()
+
+	input := idle.Reader(strings.NewReader(strings.Repeat("k", 10)))
+	buf := make([]byte, 1)
+	for range 10 {
+		time.Sleep(20 * time.Millisecond)
+		if _, err := input.Read(buf); err != nil {
+			t.Fatal(err)
+		}
+		select {
+		case <-idle.Done():
+			t.Fatal("watchdog fired while input kept arriving")
+		default:
+		}
+	}
+	if remaining := idle.Remaining(); remaining <= 40*time.Millisecond {
+		t.Errorf("Remaining() = %v right after input, want close to 60ms", remaining)
+	}
+
+	// An empty read is not activity
+	if _, err := idle.Reader(strings.NewReader("")).Read(buf); err != io.EOF {
+		t.Fatalf("empty read error = %v, want EOF", err)
+	}
+	select {
+	case <-idle.Done():
+	case <-time.After(time.Second):
+		t.Fatal("watchdog did not fire once input stopped")
+	}
+}
+
+func TestIdleWatchdogStop(t *testing.T) {
+	fastIdleChecks(t)
+	idle := NewIdleWatchdog(20 * time.Millisecond)
+	idle.Stop()
+	idle.Stop()
+
+	select {
+	case <-idle.Done():
+		t.Fatal("stopped watchdog fired")
+	case <-time.After(60 * time.Millisecond):
+	}
+}
+
+// idleWarningRecorder is a TUI model that passes on the idle warnings it gets
+type idleWarningRecorder chan idleWarningMsg
+
+func (r idleWarningRecorder) Init() tea.Cmd { return nil }
+func (r idleWarningRecorder) View() string  { return "" }
+
+func (r idleWarningRecorder) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if warning, ok := msg.(idleWarningMsg); ok {
+		r <- warning
+	}
+	return r, nil
+}
+
+func TestWatchIdleCountdown(t *testing.T) {
+	fastIdleChecks(t)
+
+	config := DefaultConfig()
+	config.Security.IdleTimeout = 200 * time.Millisecond
+	config.Security.IdleWarning = 100 * time.Millisecond
+	server := &SSHServer{config: config}
+
+	warnings := make(idleWarningRecorder, 100)
+	program := tea.NewProgram(warnings, tea.WithInput(nil), tea.WithOutput(io.Discard), tea.WithoutRenderer())
+	go program.Run()
+	defer program.Kill()
+
+	idle := NewIdleWatchdog(config.Security.IdleTimeout)
+	defer idle.Stop()
+	watching := make(chan struct{})
+	go func() {
+		server.watchIdle(context.Background(), program, idle)
+		close(watching)
+	}()
+
+	next := func() idleWarningMsg {
+		t.Helper()
+		select {
+		case warning := <-warnings:
+			return warning
+		case <-time.After(time.Second):
+			t.Fatal("no idle warning sent")
+			return idleWarningMsg{}
+		}
+	}
+
+	// The countdown starts within the warning window and counts down
+	first := next()
+	if first.remaining <= 0 || first.remaining > config.Security.IdleWarning {
+		t.Fatalf("first warning = %v, want within (0, %v]", first.remaining, config.Security.IdleWarning)
+	}
+	if second := next(); second.remaining >= first.remaining {
+		t.Errorf("countdown went from %v to %v, want it to fall", first.remaining, second.remaining)
+	}
+
+	// Input clears the warning
+	idle.Touch()
+	for warning := next(); warning.remaining != 0; warning = next() {
+	}
+
+	select {
+	case <-watching:
+	case <-time.After(time.Second):
+		t.Fatal("watchIdle did not return once the session expired")
+	}
+	select {
+	case <-idle.Done():
+	default:
+		t.Error("watchdog has not fired after watchIdle returned")
+	}
+}
@@ -0,0 +1,80 @@
+package page
+
+import (
+	"io/fs"
+	"sync"
+	"time"
+
+	"golang.org/x/net/html"
+)
+
+// Cache keeps parsed pages of one content tree, re-parsing a page only when
+// its modification time changes. It is safe for concurrent use; the cached
+// documents are shared and must not be modified.
+type Cache struct {
+	fsys  fs.FS
+	mu    sync.RWMutex
+	pages map[string]*Page
+}
+
+func NewCache(fsys fs.FS) *Cache {
+	return &Cache{fsys: fsys, pages: make(map[string]*Page)}
+}
+
+// FS returns the content tree the cache reads from
+func (c *Cache) FS() fs.FS {
+	return c.fsys
+}
+
+// Load returns the parsed page for filename, from the cache when the file
+// has not changed since it was parsed
+func (c *Cache) Load(filename string) (*Page, error) {
+	stat, err := fs.Stat(c.fsys, filename)
+	if err != nil {
+		c.mu.Lock()
+		delete(c.pages, filename)
+		c.mu.Unlock()
+		return nil, err
+	}
+
+	c.mu.RLock()
+	cached, ok := c.pages[filename]
+	c.mu.RUnlock()
+	if ok && cached.modTime.Equal(stat.ModTime()) {
+		return cached, nil
+	}
+
+	parsed, err := parsePage(c.fsys, filename, stat.ModTime())
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	c.pages[filename] = parsed
+	c.mu.Unlock()
+
+	return parsed, nil
+}
+
+func parsePage(fsys fs.FS, filename string, modTime time.Time) (*Page, error) {
+	file, err := fsys.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	doc, err := html.Parse(file)
+	if err != nil {
+		return nil, err
+	}
+
+	info := pageInfoFromDoc(doc, filename)
+	info.Filename = filename
+
+	return &Page{Info: info, Doc: doc, modTime: modTime}, nil
+}
+
+// Body returns the page's <body> element, or nil when it has none
+func (p *Page) Body() *html.Node {
+	return findBody(p.Doc)
+}
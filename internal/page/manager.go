@@ -1,8 +1,9 @@
 package page
 
 import (
-	"os"
-	"path/filepath"
+	"io/fs"
+	"path"
+	"slices"
 	"sort"
 	"strconv"
 	"strings"
@@ -10,35 +11,52 @@ import (
 	"golang.org/x/net/html"
 )
 
-var RootPath = "./resume/"
-
-func DiscoverPages(rootPath string) ([]PageInfo, error) {
-	entries, err := os.ReadDir(rootPath)
-	if err != nil {
-		return nil, err
-	}
+// DownloadsDir is the folder of files offered for download; its HTML files
+// are not pages
+const DownloadsDir = "downloads"
 
+// DiscoverPages finds every page under fsys. Pages in subdirectories belong
+// to the group named by their directory and are listed after the pages of
+// their parent directory.
+func DiscoverPages(fsys fs.FS) ([]PageInfo, error) {
 	var pages []PageInfo
 
-	for _, entry := range entries {
+	err := fs.WalkDir(fsys, ".", func(name string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
 		if entry.IsDir() {
-			continue
+			if name != "." && (name == DownloadsDir || strings.HasPrefix(entry.Name(), ".") || strings.HasPrefix(entry.Name(), "_")) {
+				return fs.SkipDir
+			}
+			return nil
 		}
 
-		if !strings.HasSuffix(entry.Name(), ".html") {
-			continue
+		if !strings.HasSuffix(name, ".html") {
+			return nil
 		}
 
-		info, err := extractPageInfo(filepath.Join(rootPath, entry.Name()))
+		info, err := extractPageInfo(fsys, name)
 		if err != nil {
-			continue
+			return nil
 		}
 
-		info.Filename = entry.Name()
+		info.Filename = name
+		if dir := path.Dir(name); dir != "." {
+			info.Group = dir
+		}
 		pages = append(pages, info)
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	sort.Slice(pages, func(i, j int) bool {
+		if pages[i].Group != pages[j].Group {
+			return slices.Compare(GroupPath(pages[i].Group), GroupPath(pages[j].Group)) < 0
+		}
 		if pages[i].Order != 0 || pages[j].Order != 0 {
 			return pages[i].Order < pages[j].Order
 		}
@@ -48,8 +66,53 @@ func DiscoverPages(rootPath string) ([]PageInfo, error) {
 	return pages, nil
 }
 
-func extractPageInfo(filePath string) (PageInfo, error) {
-	file, err := os.Open(filePath)
+// GroupPath splits a group into its directory names, outermost first
+func GroupPath(group string) []string {
+	if group == "" {
+		return nil
+	}
+	return strings.Split(group, "/")
+}
+
+// GroupTitle turns a directory name into a display title
+func GroupTitle(name string) string {
+	return titleFromName(path.Base(name))
+}
+
+// ResolveTarget turns a page-target written on page from into a path
+// relative to the content root. Targets starting with "/" are already
+// relative to the root.
+func ResolveTarget(from, target string) string {
+	if strings.HasPrefix(target, "/") {
+		return path.Clean(strings.TrimPrefix(target, "/"))
+	}
+	return path.Join(path.Dir(from), target)
+}
+
+// RelativeTarget is the inverse of ResolveTarget: the link from page from to
+// the root-relative path to
+func RelativeTarget(from, to string) string {
+	var fromDir []string
+	if dir := path.Dir(from); dir != "." {
+		fromDir = strings.Split(dir, "/")
+	}
+	toParts := strings.Split(to, "/")
+
+	common := 0
+	for common < len(fromDir) && common < len(toParts)-1 && fromDir[common] == toParts[common] {
+		common++
+	}
+
+	parts := make([]string, 0, len(fromDir)-common+len(toParts)-common)
+	for range fromDir[common:] {
+		parts = append(parts, "..")
+	}
+	parts = append(parts, toParts[common:]...)
+	return strings.Join(parts, "/")
+}
+
+func extractPageInfo(fsys fs.FS, filename string) (PageInfo, error) {
+	file, err := fsys.Open(filename)
 	if err != nil {
 		return PageInfo{}, err
 	}
@@ -60,67 +123,51 @@ func extractPageInfo(filePath string) (PageInfo, error) {
 		return PageInfo{}, err
 	}
 
+	return pageInfoFromDoc(doc, filename), nil
+}
+
+// pageInfoFromDoc reads the page-* attributes of the document's <body>,
+// deriving a title from filename when there is none
+func pageInfoFromDoc(doc *html.Node, filename string) PageInfo {
 	info := PageInfo{}
 
-	var findBody func(*html.Node)
-	findBody = func(node *html.Node) {
-		if node.Type == html.ElementNode && node.Data == "body" {
-			for _, attr := range node.Attr {
-				switch attr.Key {
-				case "page-title":
-					info.Title = attr.Val
-				case "page-desc", "page-description":
-					info.Description = attr.Val
-				case "page-order":
-					info.Order, _ = strconv.Atoi(attr.Val)
-				}
+	if body := findBody(doc); body != nil {
+		for _, attr := range body.Attr {
+			switch attr.Key {
+			case "page-title":
+				info.Title = attr.Val
+			case "page-desc", "page-description":
+				info.Description = attr.Val
+			case "page-order":
+				info.Order, _ = strconv.Atoi(attr.Val)
 			}
-			return
-		}
-		for child := node.FirstChild; child != nil; child = child.NextSibling {
-			findBody(child)
 		}
 	}
 
-	findBody(doc)
-
 	if info.Title == "" {
-		info.Title = strings.TrimSuffix(filepath.Base(filePath), ".html")
-		info.Title = strings.ReplaceAll(info.Title, "-", " ")
-		info.Title = strings.Title(info.Title)
+		info.Title = titleFromName(strings.TrimSuffix(path.Base(filename), ".html"))
 	}
 
-	return info, nil
+	return info
 }
 
-func LoadPage(filename string) (*html.Node, error) {
-	filePath := filepath.Join(RootPath, filename)
-	file, err := os.Open(filePath)
-	if err != nil {
-		return nil, err
-	}
-	defer file.Close()
+// titleFromName turns a file or directory name like "web-projects" into
+// "Web Projects"
+func titleFromName(name string) string {
+	return strings.Title(strings.ReplaceAll(name, "-", " "))
+}
 
-	doc, err := html.Parse(file)
-	if err != nil {
-		return nil, err
+// findBody returns the first <body> element under node
+func findBody(node *html.Node) *html.Node {
+	if node.Type == html.ElementNode && node.Data == "body" {
+		return node
 	}
-
-	var body *html.Node
-	var findBody func(*html.Node)
-	findBody = func(node *html.Node) {
-		if node.Type == html.ElementNode && node.Data == "body" {
-			body = node
-			return
-		}
-		for child := node.FirstChild; child != nil; child = child.NextSibling {
-			findBody(child)
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		if body := findBody(child); body != nil {
+			return body
 		}
 	}
-
-	findBody(doc)
-
-	return body, nil
+	return nil
 }
 
 func GetPageTitle(doc *html.Node) string {
@@ -1,22 +1,25 @@
 package page
 
 import (
+	"time"
+
 	"golang.org/x/net/html"
 )
 
 type PageInfo struct {
-	Filename    string
+	Filename    string // path relative to the content root, e.g. "projects/alpha.html"
+	Group       string // directory the page is in, empty at the root
 	Title       string
 	Description string
 	Order       int
 }
 
+// Page is a parsed HTML document. Sections are built from Doc by each
+// session, since their inputs hold per-visitor state.
 type Page struct {
-	Info          PageInfo
-	Boxes         []Box
-	SectionTitles []string
-	Loaded        bool
-	Doc           *html.Node
+	Info    PageInfo
+	Doc     *html.Node
+	modTime time.Time
 }
 
 type PagePosition struct {
@@ -25,14 +28,6 @@ type PagePosition struct {
 	LastVisited  int64
 }
 
-type Box struct {
-	IsPageLink bool
-	PageTarget string
-	IsNotEmpty bool
-	Texts      []string
-	Context    []any
-}
-
 type PageLink struct {
 	Target       string
 	SectionTitle string
@@ -0,0 +1,430 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/Shopify/go-lua"
+	"golang.org/x/net/html"
+
+	"github.com/BoburF/terminal-web.git/internal/page"
+)
+
+// builtinKeys are handled by the TUI before any <button> binding is consulted,
+// mapped to the button type that matches their built-in action
+var builtinKeys = map[string]string{
+	"tab":       "next-section",
+	"shift+tab": "prev-section",
+	"j":         "scroll-down",
+	"down":      "scroll-down",
+	"k":         "scroll-up",
+	"up":        "scroll-up",
+	"ctrl+c":    "exit",
+	"q":         "exit",
+	"enter":     "open-page-link",
+	"esc":       "cancel",
+	"left":      "collapse-group",
+	"right":     "expand-group",
+	"p":         "open-page-switcher",
+	"/":         "open-search",
+	"?":         "find-in-section",
+	"n":         "next-match",
+	"N":         "prev-match",
+	"0":         "jump-to-section",
+	"1":         "jump-to-section",
+	"2":         "jump-to-section",
+	"3":         "jump-to-section",
+	"4":         "jump-to-section",
+	"5":         "jump-to-section",
+	"6":         "jump-to-section",
+	"7":         "jump-to-section",
+	"8":         "jump-to-section",
+	"9":         "jump-to-section",
+}
+
+// canonicalKey is key as the TUI names it: named keys such as "shift+tab" are
+// lower case, while a single character keeps its case ("N" is shift+n)
+func canonicalKey(key string) string {
+	if utf8.RuneCountInString(key) > 1 {
+		return strings.ToLower(key)
+	}
+	return key
+}
+
+// Elements the parser understands in each part of a page
+var (
+	lintSectionElements = map[string]bool{"h1": true, "p": true, "input": true, "ul": true, "ol": true}
+	lintVoidElements    = map[string]bool{"input": true, "link": true, "meta": true, "br": true, "img": true, "hr": true}
+)
+
+// luaBindPattern finds bind("key", ...) calls in Lua scripts
+var luaBindPattern = regexp.MustCompile(`\bbind\s*\(\s*["']([^"']+)["']`)
+
+// luaErrorPattern splits "file:line: message" Lua compiler errors
+var luaErrorPattern = regexp.MustCompile(`^(.*):(\d+): (.*)$`)
+
+// LintProblem is one issue found in the content, reported as file:line: message
+type LintProblem struct {
+	File    string
+	Line    int
+	Message string
+}
+
+func (p LintProblem) String() string {
+	return fmt.Sprintf("%s:%d: %s", p.File, p.Line, p.Message)
+}
+
+// lintNode is an element with the line it starts on; x/net/html drops positions
+type lintNode struct {
+	tag      string
+	attrs    map[string]string
+	line     int
+	children []*lintNode
+}
+
+func (n *lintNode) hasClass(class string) bool {
+	return n.tag == "div" && n.attrs["class"] == class
+}
+
+// find returns the first descendant matching match, depth first
+func (n *lintNode) find(match func(*lintNode) bool) *lintNode {
+	for _, child := range n.children {
+		if match(child) {
+			return child
+		}
+		if found := child.find(match); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+// runLintCommand handles the "lint" subcommand
+func runLintCommand(args []string) {
+	flags := flag.NewFlagSet("lint", flag.ExitOnError)
+	entry := flags.String("entry", DefaultConfig().Content.Entry, "Page that must bind the Lua script")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: terminal-web lint [-entry page] [dir]")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	dir := defaultContentDir
+	if flags.NArg() > 0 {
+		dir = flags.Arg(0)
+	}
+
+	problems, err := lintContent(dir, *entry)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "lint: %v\n", err)
+		os.Exit(2)
+	}
+
+	for _, problem := range problems {
+		fmt.Println(problem)
+	}
+
+	if len(problems) > 0 {
+		fmt.Fprintf(os.Stderr, "%d problem(s) found\n", len(problems))
+		os.Exit(1)
+	}
+	fmt.Fprintln(os.Stderr, "No problems found")
+}
+
+// lintContent checks every page in dir and the Lua scripts they reference;
+// entryPage is the page sessions start on
+func lintContent(dir, entryPage string) ([]LintProblem, error) {
+	discovered, err := page.DiscoverPages(os.DirFS(dir))
+	if err != nil {
+		return nil, err
+	}
+
+	pages := make(map[string]bool)
+	for _, info := range discovered {
+		pages[info.Filename] = true
+	}
+
+	var problems []LintProblem
+	if !pages[entryPage] {
+		problems = append(problems, LintProblem{filepath.Join(dir, entryPage), 1, "entry page does not exist"})
+	}
+	// page-order sorts pages within their group
+	type groupOrder struct {
+		group string
+		order int
+	}
+	orders := make(map[groupOrder]LintProblem) // first page using each order
+	scripts := make(map[string]bool)
+
+	for _, info := range discovered {
+		path := filepath.Join(dir, filepath.FromSlash(info.Filename))
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		root := parseLintTree(data)
+		lint := pageLinter{path: path, name: info.Filename, dir: dir, pages: pages, entry: info.Filename == entryPage}
+		lint.check(root)
+		problems = append(problems, lint.problems...)
+
+		if body := root.find(func(n *lintNode) bool { return n.tag == "body" }); body != nil {
+			if value, ok := body.attrs["page-order"]; ok {
+				order, err := strconv.Atoi(value)
+				if err != nil {
+					problems = append(problems, LintProblem{path, body.line, fmt.Sprintf("page-order %q is not a number", value)})
+				} else if first, dup := orders[groupOrder{info.Group, order}]; dup {
+					problems = append(problems, LintProblem{path, body.line, fmt.Sprintf("duplicate page-order %d (also used by %s:%d)", order, first.File, first.Line)})
+				} else {
+					orders[groupOrder{info.Group, order}] = LintProblem{File: path, Line: body.line}
+				}
+			}
+		}
+
+		for _, script := range lint.scripts {
+			if !scripts[script] {
+				scripts[script] = true
+				problems = append(problems, lintLuaScript(script)...)
+			}
+		}
+	}
+
+	sort.SliceStable(problems, func(i, j int) bool {
+		if problems[i].File != problems[j].File {
+			return problems[i].File < problems[j].File
+		}
+		return problems[i].Line < problems[j].Line
+	})
+	return problems, nil
+}
+
+// parseLintTree builds an element tree with line numbers from raw HTML
+func parseLintTree(data []byte) *lintNode {
+	root := &lintNode{line: 1}
+	stack := []*lintNode{root}
+	line := 1
+
+	tokenizer := html.NewTokenizer(bytes.NewReader(data))
+	for {
+		tokenType := tokenizer.Next()
+		if tokenType == html.ErrorToken {
+			return root
+		}
+		raw := tokenizer.Raw()
+		token := tokenizer.Token()
+
+		switch tokenType {
+		case html.StartTagToken, html.SelfClosingTagToken:
+			node := &lintNode{tag: token.Data, attrs: make(map[string]string), line: line}
+			for _, attr := range token.Attr {
+				node.attrs[attr.Key] = attr.Val
+			}
+			parent := stack[len(stack)-1]
+			parent.children = append(parent.children, node)
+			if tokenType == html.StartTagToken && !lintVoidElements[node.tag] {
+				stack = append(stack, node)
+			}
+		case html.EndTagToken:
+			for i := len(stack) - 1; i > 0; i-- {
+				if stack[i].tag == token.Data {
+					stack = stack[:i]
+					break
+				}
+			}
+		}
+
+		line += bytes.Count(raw, []byte("\n"))
+	}
+}
+
+// pageLinter collects problems for one page
+type pageLinter struct {
+	path     string
+	name     string // path relative to dir, for resolving page-targets
+	dir      string
+	pages    map[string]bool
+	entry    bool
+	scripts  []string
+	problems []LintProblem
+}
+
+func (l *pageLinter) report(line int, format string, args ...any) {
+	l.problems = append(l.problems, LintProblem{l.path, line, fmt.Sprintf(format, args...)})
+}
+
+func (l *pageLinter) check(root *lintNode) {
+	head := root.find(func(n *lintNode) bool { return n.tag == "head" })
+	body := root.find(func(n *lintNode) bool { return n.tag == "body" })
+	if body == nil {
+		l.report(1, "missing <body>")
+		return
+	}
+
+	// Only the entry page's script runs; a page without one has no bindings
+	if head != nil && l.entry {
+		l.checkHead(head)
+	}
+
+	main := body.find(func(n *lintNode) bool { return n.hasClass("main") })
+	if main == nil {
+		l.report(body.line, `missing <div class="main">`)
+	} else {
+		l.checkMain(main)
+	}
+
+	controllers := body.find(func(n *lintNode) bool { return n.hasClass("controllers") })
+	if controllers == nil {
+		l.report(body.line, `missing <div class="controllers">`)
+	} else {
+		l.checkControllers(controllers)
+	}
+}
+
+// checkHead verifies Lua script links
+func (l *pageLinter) checkHead(head *lintNode) {
+	for _, node := range head.children {
+		if node.tag != Link {
+			continue
+		}
+		href, ok := node.attrs[Href]
+		if !ok || href == "" {
+			l.report(node.line, `<link> is missing "href"`)
+			continue
+		}
+
+		script := filepath.Join(l.dir, href)
+		if _, err := os.Stat(script); err != nil {
+			l.report(node.line, "script %s not found", href)
+			continue
+		}
+		l.scripts = append(l.scripts, script)
+	}
+}
+
+func (l *pageLinter) checkMain(main *lintNode) {
+	for _, section := range main.children {
+		if section.tag != "div" {
+			l.report(section.line, "unknown element <%s> in main; sections must be <div>", section.tag)
+			continue
+		}
+
+		if _, ok := section.attrs["section-title"]; !ok {
+			l.report(section.line, `section is missing "section-title"`)
+		}
+
+		if sectionType, ok := section.attrs["section-type"]; ok {
+			if sectionType != "page-link" {
+				l.report(section.line, "unknown section-type %q", sectionType)
+			} else if target, ok := section.attrs["page-target"]; !ok || target == "" {
+				l.report(section.line, `page-link section is missing "page-target"`)
+			} else if !l.pages[page.ResolveTarget(l.name, target)] {
+				l.report(section.line, "page-target %q does not match any page", target)
+			}
+		} else if _, ok := section.attrs["page-target"]; ok {
+			l.report(section.line, `page-target is ignored without section-type="page-link"`)
+		}
+
+		for _, child := range section.children {
+			if !lintSectionElements[child.tag] {
+				l.report(child.line, "unknown element <%s> in section is ignored", child.tag)
+				continue
+			}
+			switch child.tag {
+			case "input":
+				if _, ok := child.attrs["value"]; !ok {
+					l.report(child.line, `<input> is missing "value"`)
+				}
+			case "ul", "ol":
+				for _, item := range child.children {
+					if item.tag != "li" {
+						l.report(item.line, "unknown element <%s> in <%s> is ignored", item.tag, child.tag)
+					}
+				}
+			}
+		}
+	}
+}
+
+func (l *pageLinter) checkControllers(controllers *lintNode) {
+	bound := make(map[string]*lintNode)
+
+	for _, button := range controllers.children {
+		if button.tag != "button" {
+			l.report(button.line, "unknown element <%s> in controllers is ignored", button.tag)
+			continue
+		}
+
+		buttonType, hasType := button.attrs["type"]
+		if !hasType {
+			l.report(button.line, `<button> is missing "type"`)
+		}
+		key, hasBind := button.attrs["bind"]
+		if !hasBind {
+			l.report(button.line, `<button> is missing "bind"`)
+			continue
+		}
+
+		if canonical := canonicalKey(key); canonical != key {
+			l.report(button.line, "key %q never matches; key names are lower case (%q)", key, canonical)
+		}
+		if builtin, ok := builtinKeys[canonicalKey(key)]; ok && hasType && buttonType != builtin {
+			l.report(button.line, "key %q is built in (%s); button type %q never fires", key, builtin, buttonType)
+		}
+		if first, ok := bound[key]; ok {
+			l.report(button.line, "key %q is already bound on line %d", key, first.line)
+		} else {
+			bound[key] = button
+		}
+	}
+}
+
+// lintLuaScript reports syntax errors and bindings that shadow built-in keys
+func lintLuaScript(path string) []LintProblem {
+	var problems []LintProblem
+
+	file, err := os.Open(path)
+	if err != nil {
+		return []LintProblem{{path, 1, err.Error()}}
+	}
+	defer file.Close()
+
+	script, err := io.ReadAll(file)
+	if err != nil {
+		return []LintProblem{{path, 1, err.Error()}}
+	}
+
+	state := lua.NewState()
+	if err := lua.LoadBuffer(state, string(script), "@"+path, "text"); err != nil {
+		message, _ := state.ToString(-1)
+		line := 1
+		if match := luaErrorPattern.FindStringSubmatch(message); match != nil {
+			line, _ = strconv.Atoi(match[2])
+			message = match[3]
+		}
+		problems = append(problems, LintProblem{path, line, "Lua: " + message})
+	}
+
+	for i, text := range strings.Split(string(script), "\n") {
+		for _, match := range luaBindPattern.FindAllStringSubmatch(text, -1) {
+			key := match[1]
+			if canonical := canonicalKey(key); canonical != key {
+				problems = append(problems, LintProblem{path, i + 1, fmt.Sprintf("bind(%q) never matches; key names are lower case (%q)", key, canonical)})
+			}
+			// Binding quit keys is how scripts declare exit; anything else is shadowed
+			if builtin, ok := builtinKeys[canonicalKey(key)]; ok && builtin != "exit" {
+				problems = append(problems, LintProblem{path, i + 1, fmt.Sprintf("bind(%q) conflicts with the built-in key (%s)", key, builtin)})
+			}
+		}
+	}
+
+	return problems
+}
@@ -27,6 +27,9 @@ type LogEntry struct {
 	ActiveConns    int       `json:"active_connections,omitempty"`
 	Username       string    `json:"username,omitempty"`
 	SessionID      string    `json:"session_id,omitempty"`
+	Site           string    `json:"site,omitempty"`
+	File           string    `json:"file,omitempty"`
+	Bytes          int64     `json:"bytes,omitempty"`
 }
 
 // NewAuditLogger creates a new audit logger that writes to the specified file
@@ -132,6 +135,46 @@ func (al *AuditLogger) LogSessionTimeout(ip, keyFP, reason string) {
 	})
 }
 
+// LogSessionIdle logs sessions terminated due to inactivity
+func (al *AuditLogger) LogSessionIdle(ip, keyFP, sessionID string, idle time.Duration) {
+	al.Log(LogEntry{
+		Level:          "INFO",
+		Event:          "SESSION_IDLE",
+		IP:             ip,
+		KeyFingerprint: keyFP,
+		SessionID:      sessionID,
+		Duration:       idle.String(),
+		Message:        "Session terminated after idle timeout",
+	})
+}
+
+// LogDownload logs a file read over SFTP
+func (al *AuditLogger) LogDownload(ip, keyFP, sessionID, site, file string, bytes int64) {
+	al.Log(LogEntry{
+		Level:          "INFO",
+		Event:          "DOWNLOAD",
+		IP:             ip,
+		KeyFingerprint: keyFP,
+		SessionID:      sessionID,
+		Site:           site,
+		File:           file,
+		Bytes:          bytes,
+		Message:        "File downloaded over SFTP",
+	})
+}
+
+// LogSessionError logs content errors shown to a visitor instead of the page
+func (al *AuditLogger) LogSessionError(ip, sessionID, site string, err error) {
+	al.Log(LogEntry{
+		Level:     "ERROR",
+		Event:     "ERROR",
+		IP:        ip,
+		SessionID: sessionID,
+		Site:      site,
+		Message:   err.Error(),
+	})
+}
+
 // LogError logs error events
 func (al *AuditLogger) LogError(ip, operation string, err error) {
 	al.Log(LogEntry{
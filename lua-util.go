@@ -1,10 +1,10 @@
 package main
 
 import (
-	"io"
-	"log"
-	"os"
-	"sync"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
 
 	"github.com/Shopify/go-lua"
 	tea "github.com/charmbracelet/bubbletea"
@@ -16,81 +16,97 @@ const (
 	Href = "href"
 )
 
-var (
-	luaBindingRegistry = make(map[string]func() tea.Cmd)
-	luaRegistryMutex   sync.RWMutex
-	luaState           *lua.State
-)
+// bindingKeyPrefix namespaces bound Lua functions in the script's registry
+const bindingKeyPrefix = "terminal-web.bind:"
+
+// luaScript is the Lua state of a page script and the keys it binds. Every
+// session runs its own copy, so scripts never see each other's state.
+type luaScript struct {
+	state    *lua.State
+	bindings map[string]bool
+	quit     bool
+}
 
-func foundScriptToBind(node *html.Node) {
+// foundScriptToBind loads the Lua script linked from head. A page without a
+// link has no script and no error; a link to a missing or broken script is one.
+func foundScriptToBind(content fs.FS, node *html.Node) (*luaScript, error) {
 	sciptBinding, err := foundHTMLNode(node, Link, Href)
 	if err != nil {
-		log.Fatalln(err)
+		return nil, nil
 	}
 
 	pathAttr, err := foundAttr(&sciptBinding.Attr, Href)
 	if err != nil {
-		log.Fatalln(err)
-	}
-
-	file, err := os.OpenFile(RootPath+pathAttr.Val, os.O_RDONLY, 0o644)
-	if err != nil {
-		log.Fatalln(err)
+		return nil, err
 	}
 
-	script, err := io.ReadAll(file)
+	script, err := fs.ReadFile(content, path.Clean(pathAttr.Val))
 	if err != nil {
-		log.Fatalln(err)
+		return nil, fmt.Errorf("failed to read script %s: %w", pathAttr.Val, err)
 	}
 
-	err = luaRegister(string(script))
+	loaded, err := luaRegister(string(script), path.Clean(pathAttr.Val))
 	if err != nil {
-		log.Fatalln(err)
+		return nil, fmt.Errorf("failed to run script %s: %w", pathAttr.Val, err)
 	}
+	return loaded, nil
 }
 
-func luaRegister(luaScript string) error {
-	luaState = lua.NewState()
-	lua.OpenLibraries(luaState)
-
-	luaState.Register("bind", func(state *lua.State) int {
-		if state.Top() < 2 {
-			return 0
-		}
+// luaRegister runs a page script, named chunkName in error messages, in a
+// fresh Lua state
+func luaRegister(source, chunkName string) (*luaScript, error) {
+	script := &luaScript{state: lua.NewState(), bindings: make(map[string]bool)}
+	lua.OpenLibraries(script.state)
 
+	script.state.Register("bind", func(state *lua.State) int {
 		key := lua.CheckString(state, 1)
+		lua.CheckType(state, 2, lua.TypeFunction)
 
-		luaRegistryMutex.Lock()
-		if key == "ctrl+c" || key == "q" {
-			luaBindingRegistry[key] = func() tea.Cmd {
-				return tea.Quit
-			}
-		} else {
-			luaBindingRegistry[key] = func() tea.Cmd {
-				return nil
-			}
-		}
-		luaRegistryMutex.Unlock()
-
+		state.PushValue(2)
+		state.SetField(lua.RegistryIndex, bindingKeyPrefix+key)
+		script.bindings[key] = true
 		return 0
 	})
 
-	luaState.Register("quit", func(state *lua.State) int {
+	script.state.Register("quit", func(state *lua.State) int {
+		script.quit = true
 		return 0
 	})
 
-	return lua.DoString(luaState, luaScript)
+	err := lua.LoadBuffer(script.state, source, "@"+chunkName, "text")
+	if err == nil {
+		err = script.state.ProtectedCall(0, lua.MultipleReturns, 0)
+	}
+	if err != nil {
+		// The Lua error message (with its line number) is left on the stack
+		if message, ok := script.state.ToString(-1); ok {
+			return nil, errors.New(message)
+		}
+		return nil, err
+	}
+	return script, nil
 }
 
-func GetLuaBinding(key string) (func() tea.Cmd, bool) {
-	luaRegistryMutex.RLock()
-	defer luaRegistryMutex.RUnlock()
-	binding, ok := luaBindingRegistry[key]
-	return binding, ok
-}
+// run calls the function bound to key, reporting whether there is one. A
+// binding that calls quit() ends the session.
+func (s *luaScript) run(key string) (tea.Cmd, bool, error) {
+	if s == nil || !s.bindings[key] {
+		return nil, false, nil
+	}
 
-func ClearLuaBindings() {
-	luaRegistryMutex.Lock()
-	defer luaRegistryMutex.Unlock()
-	luaBindingRegistry = make(map[string]func() tea.Cmd)
+	s.quit = false
+	s.state.Field(lua.RegistryIndex, bindingKeyPrefix+key)
+	if err := s.state.ProtectedCall(0, 0, 0); err != nil {
+		message, _ := s.state.ToString(-1)
+		s.state.Pop(1)
+		if message == "" {
+			message = err.Error()
+		}
+		return nil, true, fmt.Errorf("Lua binding %q: %s", key, message)
+	}
+
+	if s.quit {
+		return tea.Quit, true, nil
+	}
+	return nil, true, nil
 }
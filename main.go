@@ -9,34 +9,79 @@ import (
 	"slices"
 
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/fsnotify/fsnotify"
 	"golang.org/x/net/html"
 	"golang.org/x/term"
 
 	"github.com/BoburF/terminal-web.git/internal/page"
 )
 
-const (
-	RootPath = "./resume/"
-)
-
 func main() {
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "config":
+			runConfigCommand(os.Args[2:])
+			return
+		case "export":
+			runExportCommand(os.Args[2:])
+			return
+		case "build-site":
+			runBuildSiteCommand(os.Args[2:])
+			return
+		case "lint":
+			runLintCommand(os.Args[2:])
+			return
+		case "preview":
+			runPreviewCommand(os.Args[2:])
+			return
+		}
+	}
+
+	configPath := flag.String("config", "", "Path to a YAML or TOML configuration file")
+	root := flag.String("root", "", "Serve content from this directory instead of the embedded resume")
 	serverMode := flag.Bool("server", false, "Run as SSH server")
 	port := flag.String("port", "", "SSH server port (overrides default 4569)")
+	httpAddr := flag.String("http", "", "Also serve the TUI to browsers on this address, e.g. :8080 (implies -server)")
+	telnetAddr := flag.String("telnet", "", "Also serve the TUI over telnet on this address, e.g. :2323 (implies -server)")
 	flag.Parse()
 
-	if *serverMode {
-		// Load default configuration
-		config := DefaultConfig()
+	loadConfig := func() (*Config, error) {
+		config, err := LoadConfig(*configPath)
+		if err != nil {
+			return nil, err
+		}
 
+		if *root != "" {
+			config.Content.Root = *root
+		}
 		// Override port if provided
 		if *port != "" {
 			config.Server.Port = *port
 		}
+		if *httpAddr != "" {
+			config.Server.HTTP = *httpAddr
+		}
+		if *telnetAddr != "" {
+			config.Server.Telnet = *telnetAddr
+		}
 
+		if err := config.Validate(); err != nil {
+			return nil, err
+		}
+		return config, nil
+	}
+
+	config, err := loadConfig()
+	if err != nil {
+		log.Fatalf("Failed to load configuration: %v", err)
+	}
+
+	if *serverMode || config.Server.HTTP != "" || config.Server.Telnet != "" {
 		sshServer, err := NewSSHServer(config)
 		if err != nil {
 			log.Fatalf("Failed to initialize SSH server: %v", err)
 		}
+		sshServer.SetConfigLoader(loadConfig)
 
 		if err := sshServer.Start(); err != nil {
 			log.Fatalf("Failed to start SSH server: %v", err)
@@ -44,10 +89,39 @@ func main() {
 		return
 	}
 
-	runLocalMode()
+	runLocalMode(config, false)
 }
 
-func runLocalMode() {
+// runConfigCommand handles the "config" subcommand
+func runConfigCommand(args []string) {
+	if len(args) == 0 || args[0] != "print" {
+		fmt.Fprintln(os.Stderr, "Usage: terminal-web config print [-config file] [-format yaml|toml]")
+		os.Exit(2)
+	}
+
+	flags := flag.NewFlagSet("config print", flag.ExitOnError)
+	configPath := flags.String("config", "", "Path to a YAML or TOML configuration file")
+	format := flags.String("format", "yaml", "Output format: yaml or toml")
+	flags.Parse(args[1:])
+
+	config, err := LoadConfig(*configPath)
+	if err != nil {
+		log.Fatalf("Failed to load configuration: %v", err)
+	}
+
+	if err := config.Print(os.Stdout, *format); err != nil {
+		log.Fatalln(err)
+	}
+
+	if err := config.Validate(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// runLocalMode runs the TUI in this terminal; with watch set it re-renders
+// whenever the content changes
+func runLocalMode(config *Config, watch bool) {
 	fd := int(os.Stdout.Fd())
 
 	if !term.IsTerminal(fd) {
@@ -61,43 +135,50 @@ func runLocalMode() {
 		return
 	}
 
-	pages, err := page.DiscoverPages(page.RootPath)
+	root, entry := config.Content.Root, config.Content.Entry
+	content := contentFS(root)
+	pages, err := page.DiscoverPages(content)
 	if err != nil {
 		log.Printf("Warning: Could not discover pages: %v", err)
 		pages = []page.PageInfo{}
 	}
 
-	file, err := os.OpenFile(RootPath+"index.html", os.O_RDONLY, 0o644)
+	theme, err := loadTheme(content)
 	if err != nil {
-		log.Fatalln(err)
+		log.Printf("Warning: Could not load theme: %v", err)
 	}
 
-	doc, err := html.Parse(file)
+	cache := page.NewCache(content)
+	state, err := loadEntryState(cache, entry, func(err error) {
+		log.Printf("Warning: %v", err)
+	})
 	if err != nil {
 		log.Fatalln(err)
 	}
-
-	for node := range doc.Descendants() {
-		if node.Data == "head" {
-			foundScriptToBind(node)
+	state.Width = width
+	state.Height = height
+	state.pages = pages
+	state.currentPageIdx = max(state.findPageIndex(entry), 0)
+	state.pageCache = cache
+	state.entry = entry
+	state.theme = theme
+
+	p := tea.NewProgram(state)
+
+	if watch {
+		watcher, err := fsnotify.NewWatcher()
+		if err != nil {
+			log.Fatalf("Failed to watch content: %v", err)
 		}
-
-		if node.Data == "body" {
-			state, err := drawTui(node)
-			if err != nil {
-				log.Fatalln(err)
-			}
-			state.Width = width
-			state.Height = height
-			state.pages = pages
-			state.currentPageIdx = 0
-
-			p := tea.NewProgram(state)
-			if _, err := p.Run(); err != nil {
-				log.Fatalln(err)
-				os.Exit(1)
-			}
+		defer watcher.Close()
+		if err := watchTree(watcher, root); err != nil {
+			log.Fatalf("Failed to watch %s: %v", root, err)
 		}
+		go watchPreview(p, watcher)
+	}
+
+	if _, err := p.Run(); err != nil {
+		log.Fatalln(err)
 	}
 }
 
@@ -0,0 +1,227 @@
+package main
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/BoburF/terminal-web.git/internal/page"
+)
+
+// openPageSwitcher shows the overlay listing every page
+func (s State) openPageSwitcher() State {
+	s.showPageSwitcher = true
+	s.switcherQuery = ""
+	s.switcherCursor = 0
+	s.pendingSectionNum = ""
+	s.lastTabPressed = false
+	return s
+}
+
+// updatePageSwitcher handles keys while the page switcher is open: typing
+// filters, arrows select, Enter opens, Esc closes and Ctrl+C quits
+func (s State) updatePageSwitcher(msg tea.KeyMsg) (State, tea.Cmd) {
+	matches := s.switcherMatches()
+
+	switch msg.String() {
+	case "ctrl+c":
+		s.quitting = true
+		return s, tea.Quit
+	case "esc":
+		s.showPageSwitcher = false
+		return s, nil
+	case "enter":
+		s.showPageSwitcher = false
+		if s.switcherCursor >= len(matches) {
+			return s, nil
+		}
+		pageIdx := matches[s.switcherCursor]
+		if pageIdx == s.currentPageIdx {
+			return s, nil
+		}
+		s.pendingPageIdx = pageIdx
+		return s.confirmPageSwitch()
+	case "up", "ctrl+p", "shift+tab":
+		if s.switcherCursor > 0 {
+			s.switcherCursor--
+		}
+		return s, nil
+	case "down", "ctrl+n", "tab":
+		if s.switcherCursor < len(matches)-1 {
+			s.switcherCursor++
+		}
+		return s, nil
+	case "backspace":
+		if query := []rune(s.switcherQuery); len(query) > 0 {
+			s.switcherQuery = string(query[:len(query)-1])
+			s.switcherCursor = 0
+		}
+		return s, nil
+	}
+
+	switch msg.Type {
+	case tea.KeyRunes:
+		s.switcherQuery += string(msg.Runes)
+		s.switcherCursor = 0
+	case tea.KeySpace:
+		s.switcherQuery += " "
+		s.switcherCursor = 0
+	}
+	return s, nil
+}
+
+// switcherMatches returns the indexes of the pages matching the query, best
+// match first; an empty query lists every page in order
+func (s State) switcherMatches() []int {
+	query := strings.TrimSpace(s.switcherQuery)
+
+	type match struct {
+		pageIdx int
+		score   int
+	}
+	var matches []match
+	for i, info := range s.pages {
+		if query == "" {
+			matches = append(matches, match{i, 0})
+			continue
+		}
+
+		best, found := 0, false
+		if score, ok := fuzzyScore(query, info.Title); ok {
+			// Titles are what visitors type, so they outrank the rest
+			best, found = score*2, true
+		}
+		for _, text := range []string{info.Description, info.Filename} {
+			if score, ok := fuzzyScore(query, text); ok && (!found || score > best) {
+				best, found = score, true
+			}
+		}
+		if found {
+			matches = append(matches, match{i, best})
+		}
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].score > matches[j].score
+	})
+
+	indexes := make([]int, len(matches))
+	for i, m := range matches {
+		indexes[i] = m.pageIdx
+	}
+	return indexes
+}
+
+// fuzzyScore matches pattern against text as a case-insensitive
+// subsequence, scoring consecutive characters and word starts higher
+func fuzzyScore(pattern, text string) (int, bool) {
+	patternRunes := []rune(strings.ToLower(pattern))
+	if len(patternRunes) == 0 {
+		return 0, true
+	}
+
+	score, next := 0, 0
+	previousMatched := false
+	previous := ' '
+	for _, r := range strings.ToLower(text) {
+		if next < len(patternRunes) && r == patternRunes[next] {
+			score++
+			if previousMatched {
+				score += 2
+			}
+			if !unicode.IsLetter(previous) && !unicode.IsDigit(previous) {
+				score += 3
+			}
+			next++
+			previousMatched = true
+		} else {
+			previousMatched = false
+		}
+		previous = r
+	}
+
+	return score, next == len(patternRunes)
+}
+
+// switcherTitle prefixes a page's title with the groups it belongs to
+func (s State) switcherTitle(info page.PageInfo) string {
+	var parts []string
+	groups := page.GroupPath(info.Group)
+	for level := range groups {
+		name := strings.Join(groups[:level+1], "/")
+		if name+"/index.html" != info.Filename {
+			parts = append(parts, s.groupTitle(name))
+		}
+	}
+	return strings.Join(append(parts, info.Title), " > ")
+}
+
+func (s State) renderPageSwitcher() string {
+	width := min(60, s.Width-4)
+	innerWidth := max(width-4, 10)
+
+	titleStyle := s.newStyle().
+		Foreground(lipgloss.Color(s.theme.Accent)).
+		Bold(true)
+
+	queryStyle := s.newStyle().
+		Foreground(lipgloss.Color(s.theme.Highlight)).
+		Bold(true)
+
+	activeStyle := s.newStyle().
+		Foreground(lipgloss.Color(s.theme.Highlight)).
+		Bold(true)
+
+	inactiveStyle := s.newStyle().
+		Foreground(lipgloss.Color(s.theme.Text))
+
+	descStyle := s.newStyle().
+		Foreground(lipgloss.Color(s.theme.Muted))
+
+	keyStyle := s.newStyle().
+		Foreground(lipgloss.Color(s.theme.Binding)).
+		Bold(true)
+
+	lines := []string{
+		titleStyle.Render("Go to page"),
+		"",
+		queryStyle.Render(truncateString("> "+s.switcherQuery+"_", innerWidth)),
+		"",
+	}
+
+	matches := s.switcherMatches()
+	// Every page takes two lines: its title and its description
+	visible := max((s.Height-12)/2, 1)
+	start := 0
+	if s.switcherCursor >= visible {
+		start = s.switcherCursor - visible + 1
+	}
+
+	if len(matches) == 0 {
+		lines = append(lines, descStyle.Render("No matching pages"))
+	}
+	for i := start; i < len(matches) && i < start+visible; i++ {
+		info := s.pages[matches[i]]
+		title := truncateString(s.switcherTitle(info), innerWidth-2)
+		if i == s.switcherCursor {
+			lines = append(lines, activeStyle.Render("→ "+title))
+		} else {
+			lines = append(lines, inactiveStyle.Render("  "+title))
+		}
+		lines = append(lines, descStyle.Render("  "+truncateString(info.Description, innerWidth-2)))
+	}
+
+	lines = append(lines, "", keyStyle.Render("[↑/↓] Select  [Enter] Open  [Esc] Close"))
+
+	box := s.newStyle().
+		Border(lipgloss.NormalBorder()).
+		BorderForeground(lipgloss.Color(s.theme.Accent)).
+		Padding(1).
+		Width(width).
+		Render(strings.Join(lines, "\n"))
+
+	return lipgloss.Place(s.Width, s.Height, lipgloss.Center, lipgloss.Center, box)
+}
@@ -0,0 +1,242 @@
+package main
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/BoburF/terminal-web.git/internal/page"
+)
+
+// pageTreeLines renders the pages as a tree for the sidebar, groups nested by
+// directory. Groups outside the current page's path stay collapsed until
+// they are selected and expanded with →; Enter opens the selected entry.
+func (s State) pageTreeLines(maxWidth int, active, inactive, group lipgloss.Style) []string {
+	var lines []string
+
+	s.walkPageTree(func(name string, level int) {
+		label := s.groupTitle(name)
+		marker := "▾ "
+		if !s.groupExpanded(name) {
+			marker = "▸ "
+			label = fmt.Sprintf("%s (%d)", label, s.countGroupPages(name))
+		}
+		indent := strings.Repeat("  ", level)
+		label = indent + marker + truncateString(label, max(maxWidth-len(indent)-2, 1))
+		if name+"/" == s.selectedEntry {
+			lines = append(lines, active.Render("› "+label))
+		} else {
+			lines = append(lines, group.Render("  "+label))
+		}
+	}, func(pageIdx, level int) {
+		indent := strings.Repeat("  ", level)
+		label := truncateString(s.pages[pageIdx].Title, max(maxWidth-len(indent), 1))
+		switch {
+		case s.pages[pageIdx].Filename == s.selectedEntry:
+			lines = append(lines, active.Render("› "+indent+label))
+		case pageIdx == s.currentPageIdx:
+			lines = append(lines, active.Render("→ "+indent+label))
+		default:
+			lines = append(lines, inactive.Render("  "+indent+label))
+		}
+	})
+
+	return lines
+}
+
+// walkPageTree visits the sidebar tree in order: onGroup for every visible
+// group header and onPage for every visible page, each with its depth
+func (s State) walkPageTree(onGroup func(name string, level int), onPage func(pageIdx, level int)) {
+	var previous []string
+
+	for i, info := range s.pages {
+		groups := page.GroupPath(info.Group)
+
+		common := 0
+		for common < len(previous) && common < len(groups) && previous[common] == groups[common] {
+			common++
+		}
+		for level := common; level < len(groups); level++ {
+			if !s.groupsExpanded(groups[:level]) {
+				break
+			}
+			onGroup(strings.Join(groups[:level+1], "/"), level)
+		}
+		previous = groups
+
+		if s.groupsExpanded(groups) {
+			onPage(i, len(groups))
+		}
+	}
+}
+
+// visibleTreeEntries lists the sidebar entries top to bottom, as
+// selectedEntry names them: group names end in "/", pages are filenames
+func (s State) visibleTreeEntries() []string {
+	var entries []string
+	s.walkPageTree(func(name string, level int) {
+		entries = append(entries, name+"/")
+	}, func(pageIdx, level int) {
+		entries = append(entries, s.pages[pageIdx].Filename)
+	})
+	return entries
+}
+
+// selectedGroup returns the group selected in the page tree, if a group is
+func (s State) selectedGroup() (string, bool) {
+	return strings.CutSuffix(s.selectedEntry, "/")
+}
+
+// expandGroup handles →: it expands the selected group, or moves the
+// selection to the next entry once there is nothing to expand
+func (s State) expandGroup() State {
+	if name, ok := s.selectedGroup(); ok && !s.groupExpanded(name) {
+		s.expandedGroups = maps.Clone(s.expandedGroups)
+		if s.expandedGroups == nil {
+			s.expandedGroups = make(map[string]bool)
+		}
+		s.expandedGroups[name] = true
+		return s
+	}
+	return s.stepTreeSelection(1)
+}
+
+// collapseGroup handles ←: it collapses the selected group, or moves the
+// selection to the previous entry when there is nothing to collapse
+func (s State) collapseGroup() State {
+	if name, ok := s.selectedGroup(); ok && s.expandedGroups[name] {
+		s.expandedGroups = maps.Clone(s.expandedGroups)
+		delete(s.expandedGroups, name)
+		// Subgroups close with their parent
+		for group := range s.expandedGroups {
+			if strings.HasPrefix(group, name+"/") {
+				delete(s.expandedGroups, group)
+			}
+		}
+		return s
+	}
+	return s.stepTreeSelection(-1)
+}
+
+// stepTreeSelection selects the next (step 1) or previous (step -1) entry in
+// the sidebar, wrapping around
+func (s State) stepTreeSelection(step int) State {
+	entries := s.visibleTreeEntries()
+	if len(entries) == 0 {
+		s.selectedEntry = ""
+		return s
+	}
+
+	current := slices.Index(entries, s.selectedEntry)
+	switch {
+	case current < 0 && step > 0:
+		s.selectedEntry = entries[0]
+	case current < 0:
+		s.selectedEntry = entries[len(entries)-1]
+	default:
+		s.selectedEntry = entries[(current+step+len(entries))%len(entries)]
+	}
+	return s
+}
+
+// openTreeSelection handles Enter on a page tree entry: a page opens, a group
+// opens its index.html or, without one, expands
+func (s State) openTreeSelection() (State, tea.Cmd) {
+	entry := s.selectedEntry
+	s.selectedEntry = ""
+
+	if name, ok := strings.CutSuffix(entry, "/"); ok {
+		entry = name + "/index.html"
+		if s.findPageIndex(entry) < 0 {
+			s.selectedEntry = name + "/"
+			if s.groupExpanded(name) {
+				return s, nil
+			}
+			return s.expandGroup(), nil
+		}
+	}
+
+	if pageIdx := s.findPageIndex(entry); pageIdx >= 0 {
+		s.pendingPageIdx = pageIdx
+		return s.confirmPageSwitch()
+	}
+	return s, nil
+}
+
+// groupExpanded reports whether a group's pages are listed: always for the
+// groups holding the current page, for the rest only once expanded
+func (s State) groupExpanded(name string) bool {
+	if s.expandedGroups[name] {
+		return true
+	}
+	current := s.currentGroup()
+	return current == name || strings.HasPrefix(current, name+"/")
+}
+
+// groupsExpanded reports whether every group along groups is expanded
+func (s State) groupsExpanded(groups []string) bool {
+	for level := range groups {
+		if !s.groupExpanded(strings.Join(groups[:level+1], "/")) {
+			return false
+		}
+	}
+	return true
+}
+
+func (s State) currentGroup() string {
+	if s.currentPageIdx >= 0 && s.currentPageIdx < len(s.pages) {
+		return s.pages[s.currentPageIdx].Group
+	}
+	return ""
+}
+
+// countGroupPages counts the pages in a group and its subgroups
+func (s State) countGroupPages(name string) int {
+	count := 0
+	for _, info := range s.pages {
+		if info.Group == name || strings.HasPrefix(info.Group, name+"/") {
+			count++
+		}
+	}
+	return count
+}
+
+// groupTitle names a group after its index.html page when it has one,
+// otherwise after its directory
+func (s State) groupTitle(name string) string {
+	if idx := s.findPageIndex(name + "/index.html"); idx >= 0 {
+		return s.pages[idx].Title
+	}
+	return page.GroupTitle(name)
+}
+
+// breadcrumbs shows where the current page sits: the entry page, the groups
+// leading to the current page and the page itself
+func (s State) breadcrumbs() string {
+	if s.currentPageIdx < 0 || s.currentPageIdx >= len(s.pages) {
+		return ""
+	}
+	current := s.pages[s.currentPageIdx]
+
+	var crumbs []string
+	if entryIdx := s.findPageIndex(s.entry); entryIdx >= 0 && entryIdx != s.currentPageIdx {
+		crumbs = append(crumbs, s.pages[entryIdx].Title)
+	}
+
+	groups := page.GroupPath(current.Group)
+	for level := range groups {
+		name := strings.Join(groups[:level+1], "/")
+		// A group's index page is the group itself
+		if name+"/index.html" == current.Filename {
+			continue
+		}
+		crumbs = append(crumbs, s.groupTitle(name))
+	}
+
+	crumbs = append(crumbs, current.Title)
+	return strings.Join(crumbs, " > ")
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/fsnotify/fsnotify"
+
+	"github.com/BoburF/terminal-web.git/internal/page"
+)
+
+// contentChangedMsg tells a previewing TUI that files under its root changed
+type contentChangedMsg struct{}
+
+// runPreviewCommand handles the "preview" subcommand: the local TUI,
+// re-rendered whenever a page or script under the content root is saved
+func runPreviewCommand(args []string) {
+	flags := flag.NewFlagSet("preview", flag.ExitOnError)
+	configPath := flags.String("config", "", "Path to a YAML or TOML configuration file")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: terminal-web preview [-config file] [dir]")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	config, err := LoadConfig(*configPath)
+	if err != nil {
+		log.Fatalf("Failed to load configuration: %v", err)
+	}
+
+	// Edits only show up on disk, never in the embedded copy
+	root := config.Content.Root
+	if flags.NArg() > 0 {
+		root = flags.Arg(0)
+	}
+	if root == "" {
+		root = defaultContentDir
+	}
+	config.Content.Root = root
+
+	runLocalMode(config, true)
+}
+
+// watchPreview watches root and sends a contentChangedMsg to p after each
+// burst of HTML, Lua or theme changes
+func watchPreview(p *tea.Program, watcher *fsnotify.Watcher) {
+	var debounce <-chan time.Time
+	for {
+		select {
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return
+			}
+			if isContentChange(watcher, event) {
+				debounce = time.After(reloadDebounce)
+			}
+		case _, ok := <-watcher.Errors:
+			if !ok {
+				return
+			}
+		case <-debounce:
+			debounce = nil
+			p.Send(contentChangedMsg{})
+		}
+	}
+}
+
+// reloadContent re-reads the pages, theme and script from disk, staying on
+// the same page and section. A broken page shows the error page until the
+// next save fixes it.
+func (s State) reloadContent() State {
+	pages, err := page.DiscoverPages(s.pageCache.FS())
+	if err != nil {
+		return s.showError(err)
+	}
+	if theme, err := loadTheme(s.pageCache.FS()); err == nil {
+		s.theme = theme
+	}
+
+	current := s.currentFilename()
+
+	// Page indexes shift when pages are added or reordered
+	oldPages := s.pages
+	s.pages = pages
+	remap := func(entries []historyEntry) []historyEntry {
+		kept := make([]historyEntry, 0, len(entries))
+		for _, entry := range entries {
+			if entry.pageIdx >= 0 && entry.pageIdx < len(oldPages) {
+				if newIdx := s.findPageIndex(oldPages[entry.pageIdx].Filename); newIdx >= 0 {
+					entry.pageIdx = newIdx
+					kept = append(kept, entry)
+				}
+			}
+		}
+		return kept
+	}
+	s.pageHistory = remap(s.pageHistory)
+	s.pageForward = remap(s.pageForward)
+	if s.showSearch {
+		s.searchIndex = s.buildSearchIndex()
+	}
+
+	pageIdx := s.findPageIndex(current)
+	if pageIdx < 0 {
+		pageIdx = s.findPageIndex(s.entry)
+	}
+	if pageIdx < 0 {
+		return s.showError(fmt.Errorf("%s was removed and there is no %s", current, s.entry))
+	}
+
+	var scriptErr error
+	entryState, _ := loadEntryState(s.pageCache, s.entry, func(err error) {
+		scriptErr = err
+	})
+	if scriptErr != nil {
+		return s.showError(scriptErr)
+	}
+	s.script = entryState.script
+
+	next, err := s.loadPage(pageIdx)
+	if err != nil {
+		return s.showError(err)
+	}
+	s = next
+	s.pageError = ""
+	s.currentPageIdx = pageIdx
+
+	s.currentSection = min(s.currentSection, len(s.boxes)-1)
+	s.currentSection = max(s.currentSection, 0)
+	s.sectionScrollOffset = min(s.sectionScrollOffset, s.getMaxScrollOffset())
+	s.sectionScrollOffset = max(s.sectionScrollOffset, 0)
+	return s
+}
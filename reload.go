@@ -0,0 +1,198 @@
+package main
+
+import (
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+
+	"github.com/BoburF/terminal-web.git/internal/page"
+)
+
+// reloadDebounce groups bursts of file events (editors often write several times per save)
+const reloadDebounce = 500 * time.Millisecond
+
+// SetConfigLoader sets the function used to re-read configuration on reload
+func (s *SSHServer) SetConfigLoader(load func() (*Config, error)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.loadConfig = load
+}
+
+// currentConfig returns the configuration new sessions should use
+func (s *SSHServer) currentConfig() *Config {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.config
+}
+
+// Reload re-reads configuration and content. Active sessions keep what they
+// started with; new sessions pick up the new limits, pages and sites.
+func (s *SSHServer) Reload() error {
+	s.mu.RLock()
+	load := s.loadConfig
+	oldConfig := s.config
+	s.mu.RUnlock()
+
+	config := oldConfig
+	if load != nil {
+		newConfig, err := load()
+		if err != nil {
+			return fmt.Errorf("failed to reload configuration: %w", err)
+		}
+		config = newConfig
+	}
+
+	if config.Server.Host != oldConfig.Server.Host || config.Server.Port != oldConfig.Server.Port || config.Server.HTTP != oldConfig.Server.HTTP ||
+		config.Server.Telnet != oldConfig.Server.Telnet ||
+		!slices.Equal(config.Server.HostKeys, oldConfig.Server.HostKeys) || config.Logging.File != oldConfig.Logging.File {
+		log.Printf("Warning: listen address, host key and log file changes require a restart; keeping current values")
+		config.Server.Host = oldConfig.Server.Host
+		config.Server.Port = oldConfig.Server.Port
+		config.Server.HTTP = oldConfig.Server.HTTP
+		config.Server.Telnet = oldConfig.Server.Telnet
+		config.Server.HostKeys = oldConfig.Server.HostKeys
+		config.Logging.File = oldConfig.Logging.File
+	}
+
+	site, err := discoverSite("", config.Content.Root)
+	if err != nil {
+		return fmt.Errorf("failed to discover pages: %w", err)
+	}
+	pages := site.Pages
+
+	// Sessions hold on to the sites they started with; new sessions get this
+	// snapshot, and any other site is rediscovered on next use
+	s.mu.Lock()
+	s.config = config
+	s.sites = map[string]Site{config.Content.Root: site}
+	s.mu.Unlock()
+
+	s.limiter.SetLimits(config.Security.RateLimitPerMinute, config.Security.MaxConnections)
+
+	s.logger.Log(LogEntry{
+		Level:       "INFO",
+		Event:       "RELOAD",
+		ActiveConns: s.limiter.getActiveCount(),
+		Message:     fmt.Sprintf("Configuration reloaded: %d pages, max %d connections, %d/min rate limit", len(pages), config.Security.MaxConnections, config.Security.RateLimitPerMinute),
+	})
+	log.Printf("Reloaded: %d pages from %s", len(pages), contentLabel(config.Content.Root))
+
+	return nil
+}
+
+// handleReloadSignals reloads the server every time SIGHUP is received
+func (s *SSHServer) handleReloadSignals() {
+	sighup := make(chan os.Signal, 1)
+	signal.Notify(sighup, syscall.SIGHUP)
+
+	for range sighup {
+		log.Printf("Received SIGHUP, reloading...")
+		if err := s.Reload(); err != nil {
+			log.Printf("Reload failed: %v", err)
+			s.logger.LogError("", "reload", err)
+		}
+	}
+}
+
+// contentDirs lists the directories whose changes should trigger a reload
+func contentDirs(config *Config) []string {
+	var dirs []string
+	if config.Content.Root != "" {
+		dirs = append(dirs, config.Content.Root)
+	}
+	if config.Content.Sites == "" {
+		return dirs
+	}
+
+	entries, err := os.ReadDir(config.Content.Sites)
+	if err != nil {
+		return dirs
+	}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			dirs = append(dirs, filepath.Join(config.Content.Sites, entry.Name()))
+		}
+	}
+	return dirs
+}
+
+// watchContent reloads the server when HTML, Lua or theme files in dirs change
+func (s *SSHServer) watchContent(dirs []string) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		log.Printf("Warning: content watcher disabled: %v", err)
+		return
+	}
+	defer watcher.Close()
+
+	for _, dir := range dirs {
+		if err := watchTree(watcher, dir); err != nil {
+			log.Printf("Warning: not watching %s: %v", dir, err)
+			continue
+		}
+		log.Printf("Watching %s for content changes", dir)
+	}
+
+	var debounce <-chan time.Time
+	for {
+		select {
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return
+			}
+			if isContentChange(watcher, event) {
+				debounce = time.After(reloadDebounce)
+			}
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return
+			}
+			log.Printf("Content watcher error: %v", err)
+		case <-debounce:
+			debounce = nil
+			if err := s.Reload(); err != nil {
+				log.Printf("Reload failed: %v", err)
+				s.logger.LogError("", "reload", err)
+			}
+		}
+	}
+}
+
+// watchTree watches dir and the subdirectories pages can be grouped in
+func watchTree(watcher *fsnotify.Watcher, dir string) error {
+	return filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !entry.IsDir() {
+			return nil
+		}
+		if path != dir && (entry.Name() == page.DownloadsDir || strings.HasPrefix(entry.Name(), ".") || strings.HasPrefix(entry.Name(), "_")) {
+			return filepath.SkipDir
+		}
+		return watcher.Add(path)
+	})
+}
+
+// isContentChange reports whether event touches a page, script or theme,
+// starting to watch directories created under a watched one
+func isContentChange(watcher *fsnotify.Watcher, event fsnotify.Event) bool {
+	if event.Has(fsnotify.Create) && isDir(event.Name) {
+		watchTree(watcher, event.Name)
+		return true
+	}
+	switch filepath.Ext(event.Name) {
+	case ".html", ".lua", ".yaml":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,325 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// maxSearchResults caps how many matches a search lists
+const maxSearchResults = 100
+
+// searchLine is one line of a section on some page, as the search sees it
+type searchLine struct {
+	pageIdx      int
+	section      int
+	line         int
+	sectionTitle string
+	text         string
+}
+
+// searchResult is a line matching the search query; start and end are the
+// byte offsets of the match in the line's text
+type searchResult struct {
+	searchLine
+	start int
+	end   int
+}
+
+// openSearch shows the search prompt, indexing the text of every page so
+// that typing only has to filter it
+func (s State) openSearch() State {
+	s.showSearch = true
+	s.searchQuery = ""
+	s.searchCursor = 0
+	s.searchIndex = s.buildSearchIndex()
+	s.pendingSectionNum = ""
+	s.lastTabPressed = false
+	return s
+}
+
+// buildSearchIndex collects the lines of every section of every page. Pages
+// that fail to load are left out; switching to them reports the error.
+func (s State) buildSearchIndex() []searchLine {
+	var index []searchLine
+
+	for pageIdx, info := range s.pages {
+		loaded, err := s.pageCache.Load(info.Filename)
+		if err != nil {
+			continue
+		}
+		boxes, sectionTitles, _, err := parseMain(loaded.Body())
+		if err != nil {
+			continue
+		}
+
+		for section, box := range boxes {
+			for line, text := range sectionLines(box) {
+				index = append(index, searchLine{
+					pageIdx:      pageIdx,
+					section:      section,
+					line:         line,
+					sectionTitle: sectionTitles[section],
+					text:         text,
+				})
+			}
+		}
+	}
+
+	return index
+}
+
+// sectionLines returns the text of a section line by line, numbered the way
+// the content pane scrolls through it
+func sectionLines(box Box) []string {
+	var lines []string
+	for _, ctx := range box.context {
+		switch content := ctx.(type) {
+		case string:
+			lines = append(lines, strings.Split(content, "\n")...)
+		case *textinput.Model:
+			lines = append(lines, content.Value())
+		}
+	}
+	return lines
+}
+
+// searchResults returns the indexed lines containing the query, ignoring case
+func (s State) searchResults() []searchResult {
+	query := strings.TrimSpace(s.searchQuery)
+	if query == "" {
+		return nil
+	}
+
+	var results []searchResult
+	for _, line := range s.searchIndex {
+		start, end := indexFold(line.text, query)
+		if start < 0 {
+			continue
+		}
+		results = append(results, searchResult{searchLine: line, start: start, end: end})
+		if len(results) == maxSearchResults {
+			break
+		}
+	}
+	return results
+}
+
+// indexFold returns the byte offsets of the first case-insensitive match of
+// query in text, or -1, -1 when there is none
+func indexFold(text, query string) (int, int) {
+	if query == "" {
+		return -1, -1
+	}
+	for start := range text {
+		if n, ok := hasPrefixFold(text[start:], query); ok {
+			return start, start + n
+		}
+	}
+	return -1, -1
+}
+
+// hasPrefixFold reports whether text starts with prefix, ignoring case, and
+// how many bytes of text the prefix covers
+func hasPrefixFold(text, prefix string) (int, bool) {
+	n := 0
+	for _, want := range prefix {
+		if n >= len(text) {
+			return 0, false
+		}
+		got, size := utf8.DecodeRuneInString(text[n:])
+		if !strings.EqualFold(string(got), string(want)) {
+			return 0, false
+		}
+		n += size
+	}
+	return n, true
+}
+
+// updateSearch handles keys while the search prompt is open: typing refines
+// the query, arrows select a result, Enter jumps to it and Ctrl+C quits
+func (s State) updateSearch(msg tea.KeyMsg) (State, tea.Cmd) {
+	results := s.searchResults()
+
+	switch msg.String() {
+	case "ctrl+c":
+		s.quitting = true
+		return s, tea.Quit
+	case "esc":
+		s.showSearch = false
+		s.searchIndex = nil
+		return s, nil
+	case "enter":
+		if s.searchCursor >= len(results) {
+			return s, nil
+		}
+		s.showSearch = false
+		s.searchIndex = nil
+		return s.jumpToResult(results[s.searchCursor])
+	case "up", "ctrl+p", "shift+tab":
+		if s.searchCursor > 0 {
+			s.searchCursor--
+		}
+		return s, nil
+	case "down", "ctrl+n", "tab":
+		if s.searchCursor < len(results)-1 {
+			s.searchCursor++
+		}
+		return s, nil
+	case "backspace":
+		if query := []rune(s.searchQuery); len(query) > 0 {
+			s.searchQuery = string(query[:len(query)-1])
+			s.searchCursor = 0
+		}
+		return s, nil
+	}
+
+	switch msg.Type {
+	case tea.KeyRunes:
+		s.searchQuery += string(msg.Runes)
+		s.searchCursor = 0
+	case tea.KeySpace:
+		s.searchQuery += " "
+		s.searchCursor = 0
+	}
+	return s, nil
+}
+
+// jumpToResult opens the result's page, recording the switch in the history,
+// and scrolls its section to the matching line
+func (s State) jumpToResult(result searchResult) (State, tea.Cmd) {
+	if result.pageIdx != s.currentPageIdx {
+		s.pendingPageIdx = result.pageIdx
+		var cmd tea.Cmd
+		s, cmd = s.confirmPageSwitch()
+		if s.pageError != "" || s.currentPageIdx != result.pageIdx {
+			return s, cmd
+		}
+	}
+
+	if result.section >= len(s.boxes) {
+		return s, nil
+	}
+	s.currentSection = result.section
+	s.sectionScrollOffset = max(min(result.line, s.getMaxScrollOffset()), 0)
+	return s, nil
+}
+
+func (s State) renderSearch() string {
+	width := min(70, s.Width-4)
+	innerWidth := max(width-4, 10)
+
+	titleStyle := s.newStyle().
+		Foreground(lipgloss.Color(s.theme.Accent)).
+		Bold(true)
+
+	queryStyle := s.newStyle().
+		Foreground(lipgloss.Color(s.theme.Highlight)).
+		Bold(true)
+
+	activeStyle := s.newStyle().
+		Foreground(lipgloss.Color(s.theme.Highlight)).
+		Bold(true)
+
+	inactiveStyle := s.newStyle().
+		Foreground(lipgloss.Color(s.theme.Text))
+
+	snippetStyle := s.newStyle().
+		Foreground(lipgloss.Color(s.theme.Muted))
+
+	matchStyle := s.newStyle().
+		Foreground(lipgloss.Color(s.theme.Highlight)).
+		Bold(true).
+		Underline(true)
+
+	keyStyle := s.newStyle().
+		Foreground(lipgloss.Color(s.theme.Binding)).
+		Bold(true)
+
+	lines := []string{
+		titleStyle.Render("Search"),
+		"",
+		queryStyle.Render(truncateString("/ "+s.searchQuery+"_", innerWidth)),
+		"",
+	}
+
+	results := s.searchResults()
+	switch {
+	case strings.TrimSpace(s.searchQuery) == "":
+		lines = append(lines, snippetStyle.Render(fmt.Sprintf("Type to search %d pages", len(s.pages))))
+	case len(results) == 0:
+		lines = append(lines, snippetStyle.Render("No matches"))
+	case len(results) == maxSearchResults:
+		lines = append(lines, snippetStyle.Render(fmt.Sprintf("First %d matches", maxSearchResults)), "")
+	case len(results) == 1:
+		lines = append(lines, snippetStyle.Render("1 match"), "")
+	default:
+		lines = append(lines, snippetStyle.Render(fmt.Sprintf("%d matches", len(results))), "")
+	}
+
+	// Every result takes two lines: where it is and the snippet
+	visible := max((s.Height-14)/2, 1)
+	start := 0
+	if s.searchCursor >= visible {
+		start = s.searchCursor - visible + 1
+	}
+
+	for i := start; i < len(results) && i < start+visible; i++ {
+		result := results[i]
+		location := truncateString(s.switcherTitle(s.pages[result.pageIdx])+" > "+result.sectionTitle, innerWidth-2)
+		if i == s.searchCursor {
+			lines = append(lines, activeStyle.Render("→ "+location))
+		} else {
+			lines = append(lines, inactiveStyle.Render("  "+location))
+		}
+
+		before, match, after := snippet(result.text, result.start, result.end, innerWidth-2)
+		lines = append(lines, "  "+snippetStyle.Render(before)+matchStyle.Render(match)+snippetStyle.Render(after))
+	}
+
+	lines = append(lines, "", keyStyle.Render("[↑/↓] Select  [Enter] Jump  [Esc] Close"))
+
+	box := s.newStyle().
+		Border(lipgloss.NormalBorder()).
+		BorderForeground(lipgloss.Color(s.theme.Accent)).
+		Padding(1).
+		Width(width).
+		Render(strings.Join(lines, "\n"))
+
+	return lipgloss.Place(s.Width, s.Height, lipgloss.Center, lipgloss.Center, box)
+}
+
+// snippet cuts text down to about width runes around the match at
+// start:end, marking cut ends with "…"
+func snippet(text string, start, end, width int) (string, string, string) {
+	before := []rune(text[:start])
+	match := []rune(text[start:end])
+	after := []rune(text[end:])
+
+	if len(match) > width {
+		return "", string(match[:max(width-1, 0)]) + "…", ""
+	}
+
+	room := width - len(match)
+	// Keep some context before the match, the rest goes after it
+	keepBefore := min(len(before), room/3)
+	if len(after) < room-keepBefore {
+		keepBefore = min(len(before), room-len(after))
+	}
+	keepAfter := min(len(after), room-keepBefore)
+
+	beforeText := string(before[len(before)-keepBefore:])
+	if keepBefore < len(before) && keepBefore > 0 {
+		beforeText = "…" + string(before[len(before)-keepBefore+1:])
+	}
+	afterText := string(after[:keepAfter])
+	if keepAfter < len(after) && keepAfter > 0 {
+		afterText = string(after[:keepAfter-1]) + "…"
+	}
+
+	return beforeText, string(match), afterText
+}
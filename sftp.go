@@ -0,0 +1,380 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gliderlabs/ssh"
+	"github.com/pkg/sftp"
+
+	"github.com/BoburF/terminal-web.git/internal/page"
+)
+
+// DownloadsDir is the folder inside a site whose files are offered over SFTP
+const DownloadsDir = page.DownloadsDir
+
+// downloadFormats are the exports offered when a site has no downloads folder
+var downloadFormats = []string{"pdf", "md", "txt", "html", "json"}
+
+// sftpHandler serves a read-only SFTP session (also used by modern scp)
+// rooted at the site's downloads folder or its generated exports
+func (s *SSHServer) sftpHandler(sess ssh.Session) {
+	if !s.beginSession() {
+		fmt.Fprintln(sess.Stderr(), errDraining)
+		sess.Exit(1)
+		return
+	}
+	defer s.handlers.Done()
+
+	ip := getClientIP(sess.RemoteAddr())
+	keyFP := ""
+	if fp, ok := sess.Context().Value("key_fp").(string); ok {
+		keyFP = fp
+	}
+	startTime := time.Now()
+	config := s.currentConfig()
+	sessionID := generateSessionID(ip, startTime)
+
+	if err := s.admit(ip, config); err != nil {
+		fmt.Fprintln(sess.Stderr(), err)
+		sess.Exit(1)
+		return
+	}
+
+	site := s.resolveSite(sess.User())
+
+	activeCount := s.limiter.incrementActive()
+	s.logger.Log(LogEntry{
+		Level:          "INFO",
+		Event:          "SFTP_START",
+		IP:             ip,
+		KeyFingerprint: keyFP,
+		Username:       sess.User(),
+		SessionID:      sessionID,
+		Site:           site.Name,
+		ActiveConns:    activeCount,
+		Message:        fmt.Sprintf("SFTP session started (%d/%d active)", activeCount, config.Security.MaxConnections),
+	})
+
+	defer func() {
+		duration := time.Since(startTime)
+		activeCount := s.limiter.decrementActive()
+		s.logger.Log(LogEntry{
+			Level:          "INFO",
+			Event:          "SFTP_END",
+			IP:             ip,
+			KeyFingerprint: keyFP,
+			SessionID:      sessionID,
+			Site:           site.Name,
+			Duration:       duration.String(),
+			ActiveConns:    activeCount,
+			Message:        fmt.Sprintf("SFTP session ended. Duration: %v", duration),
+		})
+	}()
+
+	downloads, err := openDownloads(site)
+	if err != nil {
+		s.logger.LogError(ip, "sftp downloads", err)
+		sess.Exit(1)
+		return
+	}
+	defer downloads.Close()
+
+	downloads.onRead = func(name string, n int64) {
+		s.logger.LogDownload(ip, keyFP, sessionID, site.Name, name, n)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), config.Security.MaxSessionDuration)
+	defer cancel()
+
+	// Requests count as activity for the idle timeout
+	idle := NewIdleWatchdog(config.Security.IdleTimeout)
+	defer idle.Stop()
+
+	channel := struct {
+		io.Reader
+		io.Writer
+		io.Closer
+	}{idle.Reader(sess), sess, sess}
+
+	server := sftp.NewRequestServer(channel, sftp.Handlers{
+		FileGet:  downloads,
+		FilePut:  downloads,
+		FileCmd:  downloads,
+		FileList: downloads,
+	})
+
+	go func() {
+		select {
+		case <-idle.Done():
+			s.logger.LogSessionIdle(ip, keyFP, sessionID, config.Security.IdleTimeout)
+		case <-s.forceClose:
+			s.logger.LogSessionTimeout(ip, keyFP, "Server shutting down")
+		case <-ctx.Done():
+			if ctx.Err() != context.DeadlineExceeded {
+				return
+			}
+			s.logger.LogSessionTimeout(ip, keyFP, "Maximum session duration reached")
+		}
+		server.Close()
+	}()
+
+	if err := server.Serve(); err != nil && err != io.EOF {
+		s.logger.LogError(ip, "sftp", err)
+	}
+	server.Close()
+}
+
+// downloadFS is the read-only tree offered over SFTP: either the site's
+// downloads folder or the resume rendered in every export format
+type downloadFS struct {
+	files   fs.FS             // downloads folder, nil when serving exports
+	closer  io.Closer         // releases files when it is opened from disk
+	exports map[string][]byte // generated exports by file name
+	modTime time.Time
+	onRead  func(name string, n int64)
+}
+
+// openDownloads prefers <site>/downloads/ and falls back to generated exports
+func openDownloads(site Site) (*downloadFS, error) {
+	if site.Root != "" {
+		// os.Root keeps symlinks from reaching outside the folder
+		if dir := filepath.Join(site.Root, DownloadsDir); isDir(dir) {
+			root, err := os.OpenRoot(dir)
+			if err != nil {
+				return nil, err
+			}
+			return &downloadFS{files: root.FS(), closer: root}, nil
+		}
+	} else if info, err := fs.Stat(site.Cache.FS(), DownloadsDir); err == nil && info.IsDir() {
+		files, err := fs.Sub(site.Cache.FS(), DownloadsDir)
+		if err != nil {
+			return nil, err
+		}
+		return &downloadFS{files: files}, nil
+	}
+
+	exports, modTime, err := site.Exports.get(site)
+	if err != nil {
+		return nil, err
+	}
+	return &downloadFS{exports: exports, modTime: modTime}, nil
+}
+
+// exportCache holds a site's generated exports so they are rendered once
+// rather than on every SFTP session. Reloading replaces the site, and with
+// it the cache.
+type exportCache struct {
+	mu      sync.Mutex
+	files   map[string][]byte
+	modTime time.Time
+}
+
+// get returns the site's exports, rendering them on first use
+func (c *exportCache) get(site Site) (map[string][]byte, time.Time, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.files != nil {
+		return c.files, c.modTime, nil
+	}
+
+	docs, err := loadDocuments(site.Cache, site.Pages)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+
+	files := make(map[string][]byte, len(downloadFormats))
+	for _, name := range downloadFormats {
+		exportFormat := exportFormats[name]
+		var buf bytes.Buffer
+		if err := writeDocuments(&buf, docs, exportFormat.format); err != nil {
+			return nil, time.Time{}, fmt.Errorf("failed to render %s: %w", name, err)
+		}
+		files["resume"+exportFormat.ext] = buf.Bytes()
+	}
+
+	c.files, c.modTime = files, time.Now()
+	return c.files, c.modTime, nil
+}
+
+func (d *downloadFS) Close() error {
+	if d.closer != nil {
+		return d.closer.Close()
+	}
+	return nil
+}
+
+// relPath turns an SFTP path into a name relative to the download root
+func relPath(p string) string {
+	rel := strings.TrimPrefix(path.Clean("/"+p), "/")
+	if rel == "" {
+		return "."
+	}
+	return rel
+}
+
+// Fileread opens a file for download, counting the bytes sent
+func (d *downloadFS) Fileread(r *sftp.Request) (io.ReaderAt, error) {
+	name := relPath(r.Filepath)
+
+	if d.files == nil {
+		data, ok := d.exports[name]
+		if !ok {
+			return nil, os.ErrNotExist
+		}
+		return &countingReader{ReaderAt: bytes.NewReader(data), name: name, done: d.onRead}, nil
+	}
+
+	file, err := d.files.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	readerAt, ok := file.(io.ReaderAt)
+	if info, err := file.Stat(); err != nil || !info.Mode().IsRegular() || !ok {
+		file.Close()
+		return nil, os.ErrPermission
+	}
+	return &countingReader{ReaderAt: readerAt, name: name, done: d.onRead}, nil
+}
+
+// Filewrite rejects uploads
+func (d *downloadFS) Filewrite(r *sftp.Request) (io.WriterAt, error) {
+	return nil, sftp.ErrSSHFxPermissionDenied
+}
+
+// Filecmd rejects renames, removals and other modifications
+func (d *downloadFS) Filecmd(r *sftp.Request) error {
+	return sftp.ErrSSHFxPermissionDenied
+}
+
+// Filelist answers directory listings and stat requests
+func (d *downloadFS) Filelist(r *sftp.Request) (sftp.ListerAt, error) {
+	name := relPath(r.Filepath)
+
+	switch r.Method {
+	case "List":
+		return d.list(name)
+	case "Stat":
+		info, err := d.stat(name)
+		if err != nil {
+			return nil, err
+		}
+		return fileList{info}, nil
+	default:
+		return nil, sftp.ErrSSHFxOpUnsupported
+	}
+}
+
+func (d *downloadFS) stat(name string) (os.FileInfo, error) {
+	if d.files != nil {
+		return fs.Stat(d.files, name)
+	}
+
+	if name == "." {
+		return exportInfo{name: "/", dir: true, modTime: d.modTime}, nil
+	}
+	data, ok := d.exports[name]
+	if !ok {
+		return nil, os.ErrNotExist
+	}
+	return exportInfo{name: name, size: int64(len(data)), modTime: d.modTime}, nil
+}
+
+func (d *downloadFS) list(name string) (fileList, error) {
+	if d.files == nil {
+		if name != "." {
+			return nil, os.ErrNotExist
+		}
+		list := make(fileList, 0, len(d.exports))
+		for file, data := range d.exports {
+			list = append(list, exportInfo{name: file, size: int64(len(data)), modTime: d.modTime})
+		}
+		sort.Slice(list, func(i, j int) bool { return list[i].Name() < list[j].Name() })
+		return list, nil
+	}
+
+	entries, err := fs.ReadDir(d.files, name)
+	if err != nil {
+		return nil, err
+	}
+
+	list := make(fileList, 0, len(entries))
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		list = append(list, info)
+	}
+	return list, nil
+}
+
+// fileList implements sftp.ListerAt over a fixed slice
+type fileList []os.FileInfo
+
+func (l fileList) ListAt(out []os.FileInfo, offset int64) (int, error) {
+	if offset >= int64(len(l)) {
+		return 0, io.EOF
+	}
+	n := copy(out, l[offset:])
+	if n < len(out) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+// exportInfo describes a generated export file
+type exportInfo struct {
+	name    string
+	size    int64
+	dir     bool
+	modTime time.Time
+}
+
+func (i exportInfo) Name() string       { return i.name }
+func (i exportInfo) Size() int64        { return i.size }
+func (i exportInfo) ModTime() time.Time { return i.modTime }
+func (i exportInfo) IsDir() bool        { return i.dir }
+func (i exportInfo) Sys() any           { return nil }
+
+func (i exportInfo) Mode() fs.FileMode {
+	if i.dir {
+		return fs.ModeDir | 0o555
+	}
+	return 0o444
+}
+
+// countingReader reports how many bytes were read once the transfer closes
+type countingReader struct {
+	io.ReaderAt
+	name string
+	read atomic.Int64
+	done func(name string, n int64)
+}
+
+func (c *countingReader) ReadAt(p []byte, off int64) (int, error) {
+	n, err := c.ReaderAt.ReadAt(p, off)
+	c.read.Add(int64(n))
+	return n, err
+}
+
+func (c *countingReader) Close() error {
+	if c.done != nil {
+		c.done(c.name, c.read.Load())
+	}
+	if closer, ok := c.ReaderAt.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
@@ -0,0 +1,212 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pkg/sftp"
+)
+
+func TestRelPath(t *testing.T) {
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"", "."},
+		{"/", "."},
+		{".", "."},
+		{"resume.pdf", "resume.pdf"},
+		{"/resume.pdf", "resume.pdf"},
+		{"/docs/", "docs"},
+		{"docs/../resume.pdf", "resume.pdf"},
+		{"../../etc/passwd", "etc/passwd"},
+		{"/../../etc/passwd", "etc/passwd"},
+		{"//a//b/./c", "a/b/c"},
+	}
+
+	for _, tt := range tests {
+		if got := relPath(tt.path); got != tt.want {
+			t.Errorf("relPath(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
+
+// newDownloadSite lays out a site whose downloads folder holds a file, a
+// subfolder and symlinks pointing outside it
+func newDownloadSite(t *testing.T) (Site, string) {
+	t.Helper()
+	root := t.TempDir()
+	downloads := filepath.Join(root, DownloadsDir)
+	secret := filepath.Join(root, "secret.txt")
+
+	for name, data := range map[string]string{
+		secret:                                   "secret",
+		filepath.Join(downloads, "cv.pdf"):       "%PDF",
+		filepath.Join(downloads, "docs", "a.md"): "# A",
+	} {
+		if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(name, []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Symlink("../secret.txt", filepath.Join(downloads, "relative-link")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(secret, filepath.Join(downloads, "absolute-link")); err != nil {
+		t.Fatal(err)
+	}
+	return Site{Root: root}, secret
+}
+
+func TestDownloadFSRead(t *testing.T) {
+	site, secret := newDownloadSite(t)
+
+	tests := []struct {
+		name    string
+		path    string
+		want    string
+		wantErr bool
+	}{
+		{name: "file", path: "/cv.pdf", want: "%PDF"},
+		{name: "relative path", path: "cv.pdf", want: "%PDF"},
+		{name: "nested file", path: "/docs/a.md", want: "# A"},
+		{name: "dot-dot stays inside", path: "/docs/../cv.pdf", want: "%PDF"},
+		{name: "dot-dot above the root", path: "/../secret.txt", wantErr: true},
+		{name: "absolute path outside", path: secret, wantErr: true},
+		{name: "relative symlink escape", path: "/relative-link", wantErr: true},
+		{name: "absolute symlink escape", path: "/absolute-link", wantErr: true},
+		{name: "directory", path: "/docs", wantErr: true},
+		{name: "missing", path: "/nope.pdf", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			downloads, err := openDownloads(site)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer downloads.Close()
+
+			var readName string
+			var readBytes int64
+			downloads.onRead = func(name string, n int64) { readName, readBytes = name, n }
+
+			reader, err := downloads.Fileread(sftp.NewRequest("Get", tt.path))
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("Fileread(%q) succeeded, want an error", tt.path)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Fileread(%q): %v", tt.path, err)
+			}
+
+			data, err := io.ReadAll(io.NewSectionReader(reader, 0, 1<<20))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(data) != tt.want {
+				t.Errorf("read %q, want %q", data, tt.want)
+			}
+
+			reader.(io.Closer).Close()
+			if readName != relPath(tt.path) || readBytes != int64(len(tt.want)) {
+				t.Errorf("onRead(%q, %d), want (%q, %d)", readName, readBytes, relPath(tt.path), len(tt.want))
+			}
+		})
+	}
+}
+
+func TestDownloadFSRejectsChanges(t *testing.T) {
+	site, _ := newDownloadSite(t)
+	downloads, err := openDownloads(site)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer downloads.Close()
+
+	if _, err := downloads.Filewrite(sftp.NewRequest("Put", "/upload.txt")); !errors.Is(err, sftp.ErrSSHFxPermissionDenied) {
+		t.Errorf("Filewrite error = %v, want permission denied", err)
+	}
+	for _, method := range []string{"Remove", "Rename", "Mkdir", "Rmdir", "Setstat", "Symlink"} {
+		if err := downloads.Filecmd(sftp.NewRequest(method, "/cv.pdf")); !errors.Is(err, sftp.ErrSSHFxPermissionDenied) {
+			t.Errorf("Filecmd(%s) error = %v, want permission denied", method, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(site.Root, DownloadsDir, "cv.pdf")); err != nil {
+		t.Errorf("cv.pdf after rejected changes: %v", err)
+	}
+}
+
+func TestDownloadFSList(t *testing.T) {
+	site, _ := newDownloadSite(t)
+	onDisk, err := openDownloads(site)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer onDisk.Close()
+
+	exports := &downloadFS{
+		exports: map[string][]byte{"resume.txt": []byte("txt"), "resume.md": []byte("# md")},
+		modTime: time.Now(),
+	}
+
+	tests := []struct {
+		name      string
+		downloads *downloadFS
+		method    string
+		path      string
+		want      []string // names, and "/" for a directory
+		wantErr   error
+	}{
+		{name: "folder root", downloads: onDisk, method: "List", path: "/", want: []string{"absolute-link", "cv.pdf", "docs/", "relative-link"}},
+		{name: "folder subdirectory", downloads: onDisk, method: "List", path: "/docs", want: []string{"a.md"}},
+		{name: "folder stat", downloads: onDisk, method: "Stat", path: "/cv.pdf", want: []string{"cv.pdf"}},
+		{name: "folder missing", downloads: onDisk, method: "List", path: "/nope", wantErr: os.ErrNotExist},
+		{name: "exports root", downloads: exports, method: "List", path: "/", want: []string{"resume.md", "resume.txt"}},
+		{name: "exports root stat", downloads: exports, method: "Stat", path: "/", want: []string{"//"}},
+		{name: "exports stat", downloads: exports, method: "Stat", path: "/resume.md", want: []string{"resume.md"}},
+		{name: "exports subdirectory", downloads: exports, method: "List", path: "/docs", wantErr: os.ErrNotExist},
+		{name: "unsupported method", downloads: exports, method: "Readlink", path: "/resume.md", wantErr: sftp.ErrSSHFxOpUnsupported},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lister, err := tt.downloads.Filelist(sftp.NewRequest(tt.method, tt.path))
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Filelist error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			infos := make([]os.FileInfo, 16)
+			n, err := lister.ListAt(infos, 0)
+			if err != nil && err != io.EOF {
+				t.Fatal(err)
+			}
+			var names []string
+			for _, info := range infos[:n] {
+				name := info.Name()
+				if info.IsDir() {
+					name += "/"
+				}
+				names = append(names, name)
+			}
+			if strings.Join(names, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("listed %q, want %q", names, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// shutdownGrace bounds how long ssh.Server.Shutdown may wait once sessions were told to exit
+const shutdownGrace = 5 * time.Second
+
+// errDraining is shown to visitors who connect while the server shuts down
+var errDraining = errors.New("Server is shutting down. Please reconnect in a moment.")
+
+// shutdownNoticeMsg tells a running TUI that the server is about to restart
+type shutdownNoticeMsg struct {
+	drain time.Duration
+}
+
+// trackProgram registers a running TUI so it can be notified on shutdown
+func (s *SSHServer) trackProgram(sessionID string, p *tea.Program) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.programs[sessionID] = p
+	if s.draining {
+		go p.Send(shutdownNoticeMsg{drain: s.config.Server.DrainTimeout})
+	}
+}
+
+// untrackProgram removes a TUI once it has exited
+func (s *SSHServer) untrackProgram(sessionID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.programs, sessionID)
+}
+
+// isDraining reports whether the server has stopped accepting connections
+func (s *SSHServer) isDraining() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.draining
+}
+
+// beginSession counts a new session towards the drain, refusing it once the
+// server has started shutting down. Callers must call s.handlers.Done when
+// it returns true.
+func (s *SSHServer) beginSession() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.draining {
+		return false
+	}
+	s.handlers.Add(1)
+	return true
+}
+
+// handleShutdownSignals drains the server on SIGINT or SIGTERM
+func (s *SSHServer) handleShutdownSignals() {
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM)
+
+	sig := <-sigs
+	log.Printf("Received %v, shutting down...", sig)
+	signal.Stop(sigs)
+	s.Shutdown()
+}
+
+// Shutdown stops accepting connections, warns active sessions, waits up to
+// the configured drain period for them to finish, then closes whatever is left
+func (s *SSHServer) Shutdown() {
+	s.mu.Lock()
+	if s.draining {
+		s.mu.Unlock()
+		return
+	}
+	s.draining = true
+	drain := s.config.Server.DrainTimeout
+	programs := make([]*tea.Program, 0, len(s.programs))
+	for _, p := range s.programs {
+		programs = append(programs, p)
+	}
+	s.mu.Unlock()
+
+	defer close(s.shutdownDone)
+
+	// Stop accepting new connections
+	if s.listener != nil {
+		s.listener.Close()
+	}
+	if s.httpServer != nil {
+		s.httpServer.Close()
+	}
+	if s.telnetListener != nil {
+		s.telnetListener.Close()
+	}
+
+	active := s.limiter.getActiveCount()
+	s.logger.Log(LogEntry{
+		Level:       "INFO",
+		Event:       "SHUTDOWN",
+		ActiveConns: active,
+		Message:     "Server shutting down, draining active sessions for up to " + drain.String(),
+	})
+	log.Printf("Draining %d active sessions (up to %v)", active, drain)
+
+	for _, p := range programs {
+		go p.Send(shutdownNoticeMsg{drain: drain})
+	}
+
+	sessionsDone := make(chan struct{})
+	go func() {
+		s.handlers.Wait()
+		close(sessionsDone)
+	}()
+
+	select {
+	case <-sessionsDone:
+	case <-time.After(drain):
+		log.Printf("Drain period elapsed, closing remaining sessions")
+		close(s.forceClose)
+		// Give handlers a moment to write their end-of-session audit entries
+		select {
+		case <-sessionsDone:
+		case <-time.After(shutdownGrace):
+		}
+	}
+
+	if s.server != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), shutdownGrace)
+		defer cancel()
+		if err := s.server.Shutdown(ctx); err != nil {
+			log.Printf("Forcing remaining connections closed: %v", err)
+			s.server.Close()
+		}
+	}
+
+	s.logger.Log(LogEntry{
+		Level:   "INFO",
+		Event:   "SHUTDOWN_COMPLETE",
+		Message: "Server stopped",
+	})
+	if err := s.Close(); err != nil {
+		log.Printf("Failed to close audit log: %v", err)
+	}
+}
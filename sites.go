@@ -0,0 +1,90 @@
+package main
+
+import (
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/BoburF/terminal-web.git/internal/page"
+)
+
+// Site is the content served to a particular SSH user
+type Site struct {
+	Name  string
+	Root  string      // directory on disk, empty for the embedded content
+	Cache *page.Cache // parsed pages, shared by every session on the site
+	Entry string      // page shown when a session starts
+	Theme Theme
+	Pages []page.PageInfo
+
+	Exports *exportCache // downloads rendered for SFTP when there is no downloads folder
+}
+
+// resolveSite picks the content for username: sites/<username>/ when it
+// exists, then the default site, then the single-tenant content root
+func (s *SSHServer) resolveSite(username string) Site {
+	config := s.currentConfig()
+
+	name, root := "", config.Content.Root
+	if config.Content.Sites != "" {
+		if isSafeSiteName(username) && isDir(filepath.Join(config.Content.Sites, username)) {
+			name, root = username, filepath.Join(config.Content.Sites, username)
+		} else if config.Content.DefaultSite != "" && isDir(filepath.Join(config.Content.Sites, config.Content.DefaultSite)) {
+			name, root = config.Content.DefaultSite, filepath.Join(config.Content.Sites, config.Content.DefaultSite)
+		}
+	}
+	site := s.loadSite(name, root)
+	site.Entry = config.Content.Entry
+	return site
+}
+
+// loadSite returns the cached site for root, discovering its pages and theme on first use
+func (s *SSHServer) loadSite(name, root string) Site {
+	s.mu.RLock()
+	site, ok := s.sites[root]
+	s.mu.RUnlock()
+	if ok {
+		return site
+	}
+
+	site, err := discoverSite(name, root)
+	if err != nil {
+		log.Printf("Warning: Could not discover pages in %s: %v", contentLabel(root), err)
+	}
+
+	s.mu.Lock()
+	s.sites[root] = site
+	s.mu.Unlock()
+
+	return site
+}
+
+// discoverSite reads the pages and theme under root into a new site. A site
+// whose pages cannot be discovered is returned without pages along with the
+// error.
+func discoverSite(name, root string) (Site, error) {
+	content := contentFS(root)
+	theme, err := loadTheme(content)
+	if err != nil {
+		log.Printf("Warning: Could not load theme for %s: %v", contentLabel(root), err)
+	}
+	site := Site{Name: name, Root: root, Cache: page.NewCache(content), Theme: theme, Pages: []page.PageInfo{}, Exports: &exportCache{}}
+
+	pages, err := page.DiscoverPages(content)
+	if err != nil {
+		return site, err
+	}
+	site.Pages = pages
+	return site, nil
+}
+
+// isSafeSiteName rejects usernames that could escape the sites directory
+func isSafeSiteName(name string) bool {
+	return name != "" && name != "." && name != ".." && !strings.ContainsAny(name, `/\`)
+}
+
+func isDir(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
@@ -2,11 +2,14 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"log"
 	"net"
+	"net/http"
 	"os"
+	"strings"
 	"sync"
 	"time"
 
@@ -16,7 +19,6 @@ import (
 	"github.com/gliderlabs/ssh"
 	"github.com/muesli/termenv"
 	gossh "golang.org/x/crypto/ssh"
-	"golang.org/x/net/html"
 )
 
 // ConnectionLimiter manages rate limiting and connection counting
@@ -74,6 +76,14 @@ func (cl *ConnectionLimiter) allowConnection(ip string) bool {
 	return true
 }
 
+// SetLimits updates the rate and connection limits for new connections
+func (cl *ConnectionLimiter) SetLimits(rateLimit, maxConnections int) {
+	cl.mu.Lock()
+	defer cl.mu.Unlock()
+	cl.rateLimit = rateLimit
+	cl.maxConnections = maxConnections
+}
+
 // incrementActive increments the active connection counter
 func (cl *ConnectionLimiter) incrementActive() int {
 	cl.activeMu.Lock()
@@ -101,10 +111,22 @@ func (cl *ConnectionLimiter) getActiveCount() int {
 
 // SSHServer represents the secure SSH server
 type SSHServer struct {
-	config  *Config
-	limiter *ConnectionLimiter
-	logger  *AuditLogger
-	hostKey gossh.Signer
+	config         *Config
+	limiter        *ConnectionLimiter
+	logger         *AuditLogger
+	hostKeys       []gossh.Signer
+	sites          map[string]Site // content root -> discovered site
+	loadConfig     func() (*Config, error)
+	server         *ssh.Server
+	listener       net.Listener
+	httpServer     *http.Server
+	telnetListener net.Listener
+	programs       map[string]*tea.Program // session ID -> running TUI
+	handlers       sync.WaitGroup
+	draining       bool
+	forceClose     chan struct{}
+	shutdownDone   chan struct{}
+	mu             sync.RWMutex
 }
 
 // NewSSHServer creates a new SSH server with security configuration
@@ -115,49 +137,39 @@ func NewSSHServer(config *Config) (*SSHServer, error) {
 		return nil, fmt.Errorf("failed to create audit logger: %w", err)
 	}
 
-	// Load host key
-	hostKey, err := loadHostKey(config.Server.HostKey)
+	// Load host keys, generating any that are missing
+	hostKeys, err := loadHostKeys(config.Server.HostKeys)
 	if err != nil {
 		return nil, fmt.Errorf("failed to load host key: %w", err)
 	}
 
 	return &SSHServer{
-		config:  config,
-		limiter: NewConnectionLimiter(config.Security.RateLimitPerMinute, config.Security.MaxConnections),
-		logger:  logger,
-		hostKey: hostKey,
+		config:       config,
+		limiter:      NewConnectionLimiter(config.Security.RateLimitPerMinute, config.Security.MaxConnections),
+		logger:       logger,
+		hostKeys:     hostKeys,
+		sites:        make(map[string]Site),
+		programs:     make(map[string]*tea.Program),
+		forceClose:   make(chan struct{}),
+		shutdownDone: make(chan struct{}),
 	}, nil
 }
 
-// loadHostKey loads the SSH host key from file
-func loadHostKey(path string) (gossh.Signer, error) {
-	keyBytes, err := os.ReadFile(path)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read host key file: %w", err)
-	}
-
-	signer, err := gossh.ParsePrivateKey(keyBytes)
-	if err != nil {
-		return nil, fmt.Errorf("failed to parse host key: %w", err)
-	}
-
-	return signer, nil
-}
-
 // Start begins listening for SSH connections
 func (s *SSHServer) Start() error {
-	log.Printf("Starting SSH server on %s:%s...", s.config.Server.Host, s.config.Server.Port)
+	config := s.currentConfig()
+	log.Printf("Starting SSH server on %s:%s...", config.Server.Host, config.Server.Port)
 	log.Printf("Security: max %d connections, %d/min rate limit",
-		s.config.Security.MaxConnections, s.config.Security.RateLimitPerMinute)
+		config.Security.MaxConnections, config.Security.RateLimitPerMinute)
 	log.Printf("Session limits: %v idle timeout, %v max duration",
-		s.config.Security.IdleTimeout, s.config.Security.MaxSessionDuration)
+		config.Security.IdleTimeout, config.Security.MaxSessionDuration)
 
 	server := &ssh.Server{
-		Addr:        net.JoinHostPort(s.config.Server.Host, s.config.Server.Port),
+		Addr:        net.JoinHostPort(config.Server.Host, config.Server.Port),
 		Handler:     s.secureSessionHandler,
-		HostSigners: []ssh.Signer{s.hostKey},
+		HostSigners: make([]ssh.Signer, 0, len(s.hostKeys)),
 
-		// Force PTY - prevents non-interactive bots
+		// Accept PTY requests; sessions without one get a plain-text dump
 		PtyCallback: func(ctx ssh.Context, pty ssh.Pty) bool {
 			return true
 		},
@@ -188,6 +200,11 @@ func (s *SSHServer) Start() error {
 			return false
 		},
 
+		// Read-only SFTP (and scp) access to the site's downloads
+		SubsystemHandlers: map[string]ssh.SubsystemHandler{
+			"sftp": s.sftpHandler,
+		},
+
 		// Log connection failures
 		ConnectionFailedCallback: func(conn net.Conn, err error) {
 			ip := getClientIP(conn.RemoteAddr())
@@ -195,11 +212,58 @@ func (s *SSHServer) Start() error {
 		},
 	}
 
-	return server.ListenAndServe()
+	for _, key := range s.hostKeys {
+		server.AddHostKey(key)
+	}
+	logHostKeyFingerprints(s.hostKeys)
+
+	listener, err := net.Listen("tcp", server.Addr)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.server = server
+	s.listener = listener
+	s.mu.Unlock()
+
+	if config.Server.HTTP != "" {
+		if err := s.listenHTTP(config.Server.HTTP); err != nil {
+			listener.Close()
+			return fmt.Errorf("failed to start browser terminal: %w", err)
+		}
+	}
+	if config.Server.Telnet != "" {
+		if err := s.listenTelnet(config.Server.Telnet); err != nil {
+			listener.Close()
+			return fmt.Errorf("failed to start telnet listener: %w", err)
+		}
+	}
+
+	go s.handleReloadSignals()
+	go s.handleShutdownSignals()
+	if config.Content.Watch {
+		go s.watchContent(contentDirs(config))
+	}
+
+	err = server.Serve(listener)
+	if s.isDraining() {
+		// Listener was closed by Shutdown; wait for draining to finish
+		<-s.shutdownDone
+		return nil
+	}
+	return err
 }
 
 // secureSessionHandler handles individual SSH sessions with security controls
 func (s *SSHServer) secureSessionHandler(sess ssh.Session) {
+	if !s.beginSession() {
+		fmt.Fprintln(sess.Stderr(), errDraining)
+		sess.Exit(1)
+		return
+	}
+	defer s.handlers.Done()
+
 	ip := getClientIP(sess.RemoteAddr())
 	keyFP := ""
 	if fp, ok := sess.Context().Value("key_fp").(string); ok {
@@ -207,21 +271,19 @@ func (s *SSHServer) secureSessionHandler(sess ssh.Session) {
 	}
 	username := sess.User()
 	startTime := time.Now()
+	config := s.currentConfig()
 	sessionID := generateSessionID(ip, startTime)
 
 	// Check rate limit
-	if !s.limiter.allowConnection(ip) {
-		if s.limiter.getActiveCount() >= s.config.Security.MaxConnections {
-			s.logger.LogMaxConnections(ip, s.limiter.getActiveCount())
-			fmt.Fprintln(sess, "Server is at maximum capacity. Please try again later.")
-		} else {
-			s.logger.LogRateLimit(ip)
-			fmt.Fprintln(sess, "Rate limit exceeded. Maximum 10 connections per minute.")
-		}
+	if err := s.admit(ip, config); err != nil {
+		fmt.Fprintln(sess.Stderr(), err)
 		sess.Exit(1)
 		return
 	}
 
+	// Resolve the site only once admitted, so rejected floods never touch the disk
+	site := s.resolveSite(username)
+
 	// Increment active connections
 	activeCount := s.limiter.incrementActive()
 	s.logger.LogConnect(ip, keyFP, username)
@@ -231,8 +293,9 @@ func (s *SSHServer) secureSessionHandler(sess ssh.Session) {
 		IP:             ip,
 		KeyFingerprint: keyFP,
 		SessionID:      sessionID,
+		Site:           site.Name,
 		ActiveConns:    activeCount,
-		Message:        fmt.Sprintf("Session started (%d/%d active)", activeCount, s.config.Security.MaxConnections),
+		Message:        fmt.Sprintf("Session started (%d/%d active)", activeCount, config.Security.MaxConnections),
 	})
 
 	// Decrement active connections on exit
@@ -246,35 +309,53 @@ func (s *SSHServer) secureSessionHandler(sess ssh.Session) {
 			IP:             ip,
 			KeyFingerprint: keyFP,
 			SessionID:      sessionID,
+			Site:           site.Name,
 			Duration:       duration.String(),
 			ActiveConns:    activeCount,
 			Message:        fmt.Sprintf("Session ended. Duration: %v", duration),
 		})
 	}()
 
-	// Check PTY requirement
 	ptyReq, winCh, isPty := sess.Pty()
-	if !isPty {
-		s.logger.LogError(ip, "session", fmt.Errorf("no PTY requested"))
-		fmt.Fprintln(sess, "PTY is required for this application")
-		sess.Exit(1)
+
+	// Sessions without a PTY, and "text"/"md"/"json" commands, get a rendered dump
+	if format, ok := dumpFormatForCommand(sess.Command(), isPty); ok {
+		s.serveDump(sess, site, format, isPty, sessionID)
 		return
 	}
 
+	if err := s.runTerminal(sess, ptyReq.Window.Width, ptyReq.Window.Height, termenv.TrueColor, winCh, site, sessionID, keyFP); err != nil {
+		sess.Exit(1)
+	}
+}
+
+// terminalSession is a visitor connection that can host the TUI: an SSH
+// session, a browser WebSocket or a telnet connection
+type terminalSession interface {
+	io.ReadWriter
+	Command() []string
+	RemoteAddr() net.Addr
+}
+
+// runTerminal bridges conn to a fresh PTY and runs the TUI on it until the
+// visitor quits, goes idle, the server shuts down or the session times out
+func (s *SSHServer) runTerminal(conn terminalSession, width, height int, profile termenv.Profile, winCh <-chan ssh.Window, site Site, sessionID, keyFP string) error {
+	ip := getClientIP(conn.RemoteAddr())
+	config := s.currentConfig()
+
 	// Open PTY
 	ptmx, tty, err := pty.Open()
 	if err != nil {
 		s.logger.LogError(ip, "pty open", err)
-		sess.Exit(1)
-		return
+		return err
 	}
 	defer ptmx.Close()
 	defer tty.Close()
 
 	// Set initial PTY size
 	if err := pty.Setsize(ptmx, &pty.Winsize{
-		Rows: uint16(ptyReq.Window.Height),
-		Cols: uint16(ptyReq.Window.Width),
+		Rows: uint16(height),
+		Cols: uint16(width),
 	}); err != nil {
 		s.logger.LogError(ip, "pty setsize", err)
 	}
@@ -292,84 +373,198 @@ func (s *SSHServer) secureSessionHandler(sess ssh.Session) {
 	}()
 
 	// Handle input/output with session timeout
-	ctx, cancel := context.WithTimeout(context.Background(), s.config.Security.MaxSessionDuration)
+	ctx, cancel := context.WithTimeout(context.Background(), config.Security.MaxSessionDuration)
 	defer cancel()
 
+	// Track inactivity; every input byte resets the countdown
+	idle := NewIdleWatchdog(config.Security.IdleTimeout)
+	defer idle.Stop()
+
 	// Copy input from session to PTY
 	go func() {
 		defer cancel() // Cancel context when input ends
-		io.Copy(ptmx, sess)
+		io.Copy(ptmx, idle.Reader(conn))
 	}()
 
 	// Copy output from PTY to session
 	go func() {
-		io.Copy(sess, ptmx)
+		io.Copy(conn, ptmx)
 	}()
 
+	p, err := s.newSessionProgram(tty, width, height, profile, conn, sessionID, site)
+	if err != nil {
+		log.Printf("Session %s could not render %s: %v", sessionID, contentLabel(site.Root), err)
+	}
+	go s.watchIdle(ctx, p, idle)
+
 	// Run TUI with timeout monitoring
-	done := make(chan bool)
+	done := make(chan bool, 1)
 	go func() {
-		s.runTUIWithTimeout(ctx, tty, ptyReq.Window.Width, ptyReq.Window.Height, sess, sessionID)
+		s.trackProgram(sessionID, p)
+		if _, err := p.Run(); err != nil && !errors.Is(err, tea.ErrProgramKilled) {
+			log.Printf("Error running TUI: %v", err)
+		}
+		s.untrackProgram(sessionID)
 		done <- true
 	}()
 
-	// Wait for either TUI completion or timeout
+	// Wait for TUI completion, idle timeout or max duration
 	select {
 	case <-done:
 		// Normal exit
+		return nil
+	case <-idle.Done():
+		s.logger.LogSessionIdle(ip, keyFP, sessionID, config.Security.IdleTimeout)
+		stopProgram(p, done)
+		fmt.Fprintln(conn, "\r\nSession timeout: Disconnected due to inactivity.")
+	case <-s.forceClose:
+		s.logger.LogSessionTimeout(ip, keyFP, "Server shutting down")
+		stopProgram(p, done)
+		fmt.Fprintln(conn, "\r\nServer restarting. Please reconnect in a moment.")
 	case <-ctx.Done():
 		s.logger.LogSessionTimeout(ip, keyFP, "Maximum session duration reached")
-		fmt.Fprintln(sess, "\r\nSession timeout: Maximum duration reached.")
-		sess.Exit(0)
+		stopProgram(p, done)
+		fmt.Fprintln(conn, "\r\nSession timeout: Maximum duration reached.")
 	}
+	return nil
+}
+
+// stopProgram kills a TUI the visitor did not quit and waits for it to
+// restore the terminal
+func stopProgram(p *tea.Program, done <-chan bool) {
+	p.Kill()
+	<-done
+}
+
+// admit applies the connection limiter, returning the reason shown to
+// rejected visitors
+func (s *SSHServer) admit(ip string, config *Config) error {
+	if s.limiter.allowConnection(ip) {
+		return nil
+	}
+
+	if s.limiter.getActiveCount() >= config.Security.MaxConnections {
+		s.logger.LogMaxConnections(ip, s.limiter.getActiveCount())
+		return errors.New("Server is at maximum capacity. Please try again later.")
+	}
+	s.logger.LogRateLimit(ip)
+	return fmt.Errorf("Rate limit exceeded. Maximum %d connections per minute.", config.Security.RateLimitPerMinute)
 }
 
-// runTUIWithTimeout runs the TUI with session timeout handling
-func (s *SSHServer) runTUIWithTimeout(ctx context.Context, tty *os.File, width, height int, sess ssh.Session, sessionID string) {
+// newSessionProgram prepares the TUI for a session. A broken entry page is
+// shown to the visitor as an error page, audited and returned.
+func (s *SSHServer) newSessionProgram(tty *os.File, width, height int, profile termenv.Profile, sess terminalSession, sessionID string, site Site) (*tea.Program, error) {
 	// Set TERM environment variable for color support in lipgloss (before anything else)
 	os.Setenv("TERM", "xterm-256color")
 	os.Setenv("COLORTERM", "truecolor")
 
-	file, err := os.OpenFile(RootPath+"index.html", os.O_RDONLY, 0o644)
+	ip := getClientIP(sess.RemoteAddr())
+	reportError := func(err error) {
+		s.logger.LogSessionError(ip, sessionID, site.Name, err)
+	}
+
+	state, err := loadEntryState(site.Cache, site.Entry, reportError)
 	if err != nil {
-		fmt.Fprintf(tty, "Error opening resume: %v\r\n", err)
-		return
+		reportError(err)
+		state = State{}.showError(err)
+	}
+	state.Width = width
+	state.Height = height
+	state.session = sess
+	state.pages = site.Pages
+	state.currentPageIdx = max(state.findPageIndex(site.Entry), 0)
+	state.pageCache = site.Cache
+	state.entry = site.Entry
+	state.theme = site.Theme
+	state.onError = reportError
+	state.renderer = lipgloss.NewRenderer(tty)
+	state.renderer.SetColorProfile(profile)
+
+	// "ssh host -t portfolio#Projects" opens straight at that page and section
+	if link, ok := parseDeepLink(sess.Command()); ok && err == nil {
+		entry := LogEntry{
+			Level:     "INFO",
+			Event:     "DEEP_LINK",
+			IP:        ip,
+			SessionID: sessionID,
+			Site:      site.Name,
+			Message:   fmt.Sprintf("Opened deep link %q", strings.Join(sess.Command(), " ")),
+		}
+		var linkErr error
+		if state, linkErr = state.openDeepLink(link); linkErr != nil {
+			entry.Level = "WARN"
+			entry.Message = fmt.Sprintf("Deep link %q not resolved: %v", strings.Join(sess.Command(), " "), linkErr)
+		}
+		s.logger.Log(entry)
+	}
+
+	// Components styled outside the state (text inputs) use the default
+	// renderer, so force true color there
+	lipgloss.SetColorProfile(termenv.TrueColor)
+
+	p := tea.NewProgram(
+		state,
+		tea.WithInput(tty),
+		tea.WithOutput(tty),
+		tea.WithAltScreen(),
+		// Process signals belong to the server (reload/shutdown), not each session
+		tea.WithoutSignalHandler(),
+	)
+
+	return p, err
+}
+
+// serveDump writes every page of the site in the given format and exits
+func (s *SSHServer) serveDump(sess ssh.Session, site Site, format string, isPty bool, sessionID string) {
+	ip := getClientIP(sess.RemoteAddr())
+
+	var out io.Writer = sess
+	if isPty {
+		out = crlfWriter{w: sess}
 	}
-	defer file.Close()
 
-	doc, err := html.Parse(file)
+	docs, err := loadDocuments(site.Cache, site.Pages)
+	if err == nil {
+		err = writeDocuments(out, docs, format)
+	}
 	if err != nil {
-		fmt.Fprintf(tty, "Error parsing resume: %v\r\n", err)
+		s.logger.LogError(ip, "dump", err)
+		fmt.Fprintln(sess.Stderr(), "Failed to render content")
+		sess.Exit(1)
 		return
 	}
 
-	for node := range doc.Descendants() {
-		if node.Data == "head" {
-			foundScriptToBind(node)
-		}
+	s.logger.Log(LogEntry{
+		Level:     "INFO",
+		Event:     "DUMP",
+		IP:        ip,
+		SessionID: sessionID,
+		Site:      site.Name,
+		Message:   fmt.Sprintf("Served %s dump of %d pages", format, len(docs)),
+	})
+	sess.Exit(0)
+}
 
-		if node.Data == "body" {
-			state, err := drawTui(node)
-			if err != nil {
-				fmt.Fprintf(tty, "Error creating TUI: %v\r\n", err)
-				return
-			}
-			state.Width = width
-			state.Height = height
-			state.session = sess
-
-			// Force true color profile for lipgloss rendering
-			lipgloss.SetColorProfile(termenv.TrueColor)
-
-			p := tea.NewProgram(
-				state,
-				tea.WithInput(tty),
-				tea.WithOutput(tty),
-				tea.WithAltScreen(),
-			)
-
-			if _, err := p.Run(); err != nil {
-				log.Printf("Error running TUI: %v", err)
+// watchIdle sends a countdown to the TUI once the session nears its idle timeout
+func (s *SSHServer) watchIdle(ctx context.Context, p *tea.Program, idle *IdleWatchdog) {
+	ticker := time.NewTicker(idleCheckInterval)
+	defer ticker.Stop()
+
+	warning := false
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-idle.Done():
+			return
+		case <-ticker.C:
+			remaining := idle.Remaining()
+			if remaining <= s.currentConfig().Security.IdleWarning {
+				warning = true
+				p.Send(idleWarningMsg{remaining: remaining})
+			} else if warning {
+				warning = false
+				p.Send(idleWarningMsg{})
 			}
 		}
 	}
@@ -0,0 +1,367 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gliderlabs/ssh"
+	"github.com/muesli/termenv"
+)
+
+// Telnet commands and options (RFC 854, 1091, 1073)
+const (
+	telnetSE   = 240
+	telnetSB   = 250
+	telnetWILL = 251
+	telnetWONT = 252
+	telnetDO   = 253
+	telnetDONT = 254
+	telnetIAC  = 255
+
+	telnetOptEcho  = 1
+	telnetOptSGA   = 3
+	telnetOptTType = 24
+	telnetOptNAWS  = 31
+
+	telnetTTypeIs   = 0
+	telnetTTypeSend = 1
+)
+
+// telnetMaxSubnegotiation caps a subnegotiation's payload: NAWS needs 5
+// bytes and terminal types are at most 40 characters (RFC 1091)
+const telnetMaxSubnegotiation = 64
+
+// telnetNegotiationWait bounds how long a client may take to report its
+// window size and terminal type before the TUI starts with defaults
+const telnetNegotiationWait = time.Second
+
+// listenTelnet starts the telnet listener alongside the SSH server
+func (s *SSHServer) listenTelnet(addr string) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.telnetListener = listener
+	s.mu.Unlock()
+
+	log.Printf("Serving telnet on %s", listener.Addr())
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				if !s.isDraining() {
+					log.Printf("Telnet listener stopped: %v", err)
+				}
+				return
+			}
+			go s.telnetHandler(conn)
+		}
+	}()
+	return nil
+}
+
+// telnetHandler negotiates window size and terminal type, then runs the
+// same TUI SSH visitors get
+func (s *SSHServer) telnetHandler(conn net.Conn) {
+	defer conn.Close()
+	if !s.beginSession() {
+		fmt.Fprintf(conn, "%v\r\n", errDraining)
+		return
+	}
+	defer s.handlers.Done()
+
+	ip := getClientIP(conn.RemoteAddr())
+	startTime := time.Now()
+	config := s.currentConfig()
+	sessionID := generateSessionID(ip, startTime)
+
+	// Check rate limit
+	if err := s.admit(ip, config); err != nil {
+		fmt.Fprintf(conn, "%v\r\n", err)
+		return
+	}
+
+	site := s.resolveSite("")
+
+	winCh := make(chan ssh.Window, 1)
+	sess := newTelnetSession(conn, winCh)
+
+	// The server echoes (the TUI draws everything), the client reports its size and type
+	sess.command(telnetWILL, telnetOptEcho)
+	sess.command(telnetWILL, telnetOptSGA)
+	sess.command(telnetDO, telnetOptSGA)
+	sess.command(telnetDO, telnetOptNAWS)
+	sess.command(telnetDO, telnetOptTType)
+
+	width, height := defaultCols, defaultRows
+	termType := "unknown"
+	timeout := time.After(telnetNegotiationWait)
+	for gotSize, gotType := false, false; !gotSize || !gotType; {
+		select {
+		case win, ok := <-winCh:
+			if !ok {
+				return
+			}
+			width, height, gotSize = win.Width, win.Height, true
+		case termType = <-sess.termType:
+			gotType = true
+		case <-timeout:
+			gotSize, gotType = true, true
+		}
+	}
+
+	activeCount := s.limiter.incrementActive()
+	s.logger.Log(LogEntry{
+		Level:       "INFO",
+		Event:       "SESSION_START",
+		IP:          ip,
+		Username:    "telnet",
+		SessionID:   sessionID,
+		Site:        site.Name,
+		ActiveConns: activeCount,
+		Message: fmt.Sprintf("Telnet session started (%d/%d active, terminal %s, %dx%d)",
+			activeCount, config.Security.MaxConnections, termType, width, height),
+	})
+
+	defer func() {
+		duration := time.Since(startTime)
+		activeCount := s.limiter.decrementActive()
+		s.logger.Log(LogEntry{
+			Level:       "INFO",
+			Event:       "SESSION_END",
+			IP:          ip,
+			SessionID:   sessionID,
+			Site:        site.Name,
+			Duration:    duration.String(),
+			ActiveConns: activeCount,
+			Message:     fmt.Sprintf("Telnet session ended. Duration: %v", duration),
+		})
+	}()
+
+	s.runTerminal(sess, width, height, telnetColorProfile(termType), winCh, site, sessionID, "")
+}
+
+// telnetColorProfile picks the colors to render with from the terminal type
+// the client reported; clients that report none get the basic 16 colors
+func telnetColorProfile(termType string) termenv.Profile {
+	termType = strings.ToLower(termType)
+	switch {
+	case strings.Contains(termType, "truecolor"), strings.Contains(termType, "24bit"), strings.Contains(termType, "direct"):
+		return termenv.TrueColor
+	case strings.Contains(termType, "256"):
+		return termenv.ANSI256
+	case termType == "dumb", strings.HasPrefix(termType, "vt1"), strings.HasPrefix(termType, "vt2"):
+		return termenv.Ascii
+	default:
+		return termenv.ANSI
+	}
+}
+
+// telnetSession adapts a telnet connection to a terminalSession, stripping
+// protocol commands from the input and escaping IAC bytes in the output
+type telnetSession struct {
+	conn     net.Conn
+	input    *io.PipeReader
+	termType chan string
+	writeMu  sync.Mutex
+}
+
+func newTelnetSession(conn net.Conn, winCh chan ssh.Window) *telnetSession {
+	pr, pw := io.Pipe()
+	sess := &telnetSession{conn: conn, input: pr, termType: make(chan string, 1)}
+	go sess.readLoop(pw, winCh)
+	return sess
+}
+
+// readLoop decodes the telnet stream: data goes to input, window sizes to
+// winCh and the terminal type to termType. A client sending an oversized
+// subnegotiation is disconnected.
+func (ts *telnetSession) readLoop(input *io.PipeWriter, winCh chan ssh.Window) {
+	defer close(winCh)
+	defer input.Close()
+
+	const (
+		stateData = iota
+		stateIAC
+		stateOption
+		stateSub
+		stateSubIAC
+	)
+
+	state := stateData
+	var verb byte
+	var sub []byte
+	lastCR := false
+	buf := make([]byte, 1024)
+
+	for {
+		n, err := ts.conn.Read(buf)
+		if err != nil {
+			return
+		}
+
+		data := make([]byte, 0, n)
+		for _, b := range buf[:n] {
+			switch state {
+			case stateData:
+				switch {
+				case b == telnetIAC:
+					state = stateIAC
+				case lastCR && (b == 0 || b == '\n'):
+					// Enter arrives as CR NUL or CR LF; the TUI expects a lone CR
+					lastCR = false
+				default:
+					lastCR = b == '\r'
+					data = append(data, b)
+				}
+			case stateIAC:
+				switch b {
+				case telnetIAC:
+					data = append(data, b)
+					state = stateData
+				case telnetWILL, telnetWONT, telnetDO, telnetDONT:
+					verb = b
+					state = stateOption
+				case telnetSB:
+					sub = sub[:0]
+					state = stateSub
+				default:
+					state = stateData
+				}
+			case stateOption:
+				ts.negotiate(verb, b)
+				state = stateData
+			case stateSub:
+				if b == telnetIAC {
+					state = stateSubIAC
+				} else if len(sub) == telnetMaxSubnegotiation {
+					ts.conn.Close()
+					return
+				} else {
+					sub = append(sub, b)
+				}
+			case stateSubIAC:
+				switch b {
+				case telnetSE:
+					ts.subnegotiation(sub, winCh)
+					state = stateData
+				case telnetIAC:
+					if len(sub) == telnetMaxSubnegotiation {
+						ts.conn.Close()
+						return
+					}
+					sub = append(sub, b)
+					state = stateSub
+				default:
+					state = stateData
+				}
+			}
+		}
+
+		if len(data) > 0 {
+			if _, err := input.Write(data); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// negotiate answers option requests; replies to WONT/DONT are never sent
+// so negotiation cannot loop
+func (ts *telnetSession) negotiate(verb, option byte) {
+	switch verb {
+	case telnetWILL:
+		switch option {
+		case telnetOptTType:
+			ts.write([]byte{telnetIAC, telnetSB, telnetOptTType, telnetTTypeSend, telnetIAC, telnetSE})
+		case telnetOptNAWS, telnetOptSGA:
+		default:
+			ts.command(telnetDONT, option)
+		}
+	case telnetDO:
+		switch option {
+		case telnetOptEcho, telnetOptSGA:
+		default:
+			ts.command(telnetWONT, option)
+		}
+	}
+}
+
+// subnegotiation handles NAWS window sizes and TTYPE replies
+func (ts *telnetSession) subnegotiation(sub []byte, winCh chan ssh.Window) {
+	if len(sub) == 0 {
+		return
+	}
+
+	switch sub[0] {
+	case telnetOptNAWS:
+		if len(sub) >= 5 {
+			width := int(sub[1])<<8 | int(sub[2])
+			height := int(sub[3])<<8 | int(sub[4])
+			if width > 0 && height > 0 {
+				sendWindow(winCh, ssh.Window{Width: width, Height: height})
+			}
+		}
+	case telnetOptTType:
+		if len(sub) > 1 && sub[1] == telnetTTypeIs {
+			select {
+			case ts.termType <- string(sub[2:]):
+			default:
+			}
+		}
+	}
+}
+
+// sendWindow queues a window size without blocking the read loop, replacing
+// a size nobody has picked up yet
+func sendWindow(winCh chan ssh.Window, win ssh.Window) {
+	for {
+		select {
+		case winCh <- win:
+			return
+		default:
+		}
+		select {
+		case <-winCh:
+		default:
+		}
+	}
+}
+
+func (ts *telnetSession) command(verb, option byte) {
+	ts.write([]byte{telnetIAC, verb, option})
+}
+
+func (ts *telnetSession) write(p []byte) error {
+	ts.writeMu.Lock()
+	defer ts.writeMu.Unlock()
+	_, err := ts.conn.Write(p)
+	return err
+}
+
+func (ts *telnetSession) Read(p []byte) (int, error) {
+	return ts.input.Read(p)
+}
+
+func (ts *telnetSession) Write(p []byte) (int, error) {
+	if err := ts.write(bytes.ReplaceAll(p, []byte{telnetIAC}, []byte{telnetIAC, telnetIAC})); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func (ts *telnetSession) Command() []string {
+	return nil
+}
+
+func (ts *telnetSession) RemoteAddr() net.Addr {
+	return ts.conn.RemoteAddr()
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"net"
+	"testing"
+
+	"github.com/gliderlabs/ssh"
+	"github.com/muesli/termenv"
+)
+
+func TestTelnetReadLoop(t *testing.T) {
+	iac := func(b ...byte) []byte { return append([]byte{telnetIAC}, b...) }
+	sub := func(payload ...byte) []byte {
+		return append(append(iac(telnetSB), payload...), telnetIAC, telnetSE)
+	}
+
+	tests := []struct {
+		name         string
+		input        [][]byte
+		wantData     string
+		wantWindow   *ssh.Window
+		wantTermType string
+	}{
+		{
+			name:     "plain data",
+			input:    [][]byte{[]byte("hello")},
+			wantData: "hello",
+		},
+		{
+			name:     "escaped IAC is data",
+			input:    [][]byte{[]byte("a"), iac(telnetIAC), []byte("b")},
+			wantData: "a\xffb",
+		},
+		{
+			name:     "CR NUL becomes CR",
+			input:    [][]byte{[]byte("a\r\x00b")},
+			wantData: "a\rb",
+		},
+		{
+			name:     "CR LF becomes CR",
+			input:    [][]byte{[]byte("a\r\nb\r\n")},
+			wantData: "a\rb\r",
+		},
+		{
+			name:     "CR NUL split across reads",
+			input:    [][]byte{[]byte("a\r"), []byte("\x00b")},
+			wantData: "a\rb",
+		},
+		{
+			name:     "option commands are stripped",
+			input:    [][]byte{[]byte("a"), iac(telnetDO, telnetOptSGA), iac(telnetWONT, telnetOptEcho), []byte("b")},
+			wantData: "ab",
+		},
+		{
+			name:       "window size",
+			input:      [][]byte{sub(telnetOptNAWS, 0, 120, 0, 40), []byte("x")},
+			wantData:   "x",
+			wantWindow: &ssh.Window{Width: 120, Height: 40},
+		},
+		{
+			name:       "window size with escaped IAC",
+			input:      [][]byte{sub(telnetOptNAWS, 1, telnetIAC, telnetIAC, 0, 50)},
+			wantWindow: &ssh.Window{Width: 511, Height: 50},
+		},
+		{
+			name:       "latest window size wins",
+			input:      [][]byte{sub(telnetOptNAWS, 0, 80, 0, 24), sub(telnetOptNAWS, 0, 100, 0, 30)},
+			wantWindow: &ssh.Window{Width: 100, Height: 30},
+		},
+		{
+			name:  "zero window size is ignored",
+			input: [][]byte{sub(telnetOptNAWS, 0, 0, 0, 24)},
+		},
+		{
+			name:         "terminal type",
+			input:        [][]byte{sub(append([]byte{telnetOptTType, telnetTTypeIs}, "XTERM-256COLOR"...)...)},
+			wantTermType: "XTERM-256COLOR",
+		},
+		{
+			name:     "oversized subnegotiation drops the connection",
+			input:    [][]byte{[]byte("a"), iac(telnetSB, telnetOptTType), bytes.Repeat([]byte("x"), telnetMaxSubnegotiation), []byte("b")},
+			wantData: "a",
+		},
+		{
+			name:     "oversized escaped subnegotiation drops the connection",
+			input:    [][]byte{iac(telnetSB, telnetOptNAWS), bytes.Repeat(iac(telnetIAC), telnetMaxSubnegotiation), []byte("b")},
+			wantData: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, client := net.Pipe()
+			defer server.Close()
+
+			winCh := make(chan ssh.Window, 1)
+			sess := newTelnetSession(server, winCh)
+
+			// Replies to negotiation are not under test
+			go io.Copy(io.Discard, client)
+			go func() {
+				for _, chunk := range tt.input {
+					if _, err := client.Write(chunk); err != nil {
+						break
+					}
+				}
+				client.Close()
+			}()
+
+			data, err := io.ReadAll(sess)
+			if err != nil {
+				t.Fatalf("read: %v", err)
+			}
+			if string(data) != tt.wantData {
+				t.Errorf("data = %q, want %q", data, tt.wantData)
+			}
+
+			var window *ssh.Window
+			for win := range winCh {
+				window = &win
+			}
+			switch {
+			case tt.wantWindow == nil && window != nil:
+				t.Errorf("window = %+v, want none", *window)
+			case tt.wantWindow != nil && (window == nil || *window != *tt.wantWindow):
+				t.Errorf("window = %v, want %+v", window, *tt.wantWindow)
+			}
+
+			termType := ""
+			select {
+			case termType = <-sess.termType:
+			default:
+			}
+			if termType != tt.wantTermType {
+				t.Errorf("terminal type = %q, want %q", termType, tt.wantTermType)
+			}
+		})
+	}
+}
+
+func TestTelnetWriteEscapesIAC(t *testing.T) {
+	server, client := net.Pipe()
+	defer client.Close()
+
+	ts := &telnetSession{conn: server}
+	go func() {
+		ts.Write([]byte{'a', telnetIAC, 'b'})
+		server.Close()
+	}()
+
+	got, err := io.ReadAll(client)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []byte{'a', telnetIAC, telnetIAC, 'b'}; !bytes.Equal(got, want) {
+		t.Errorf("wrote %v, want %v", got, want)
+	}
+}
+
+func TestTelnetColorProfile(t *testing.T) {
+	tests := []struct {
+		termType string
+		want     termenv.Profile
+	}{
+		{"XTERM-TRUECOLOR", termenv.TrueColor},
+		{"xterm-direct", termenv.TrueColor},
+		{"XTERM-256COLOR", termenv.ANSI256},
+		{"screen-256color", termenv.ANSI256},
+		{"XTERM", termenv.ANSI},
+		{"ANSI", termenv.ANSI},
+		{"unknown", termenv.ANSI},
+		{"VT100", termenv.Ascii},
+		{"vt220", termenv.Ascii},
+		{"DUMB", termenv.Ascii},
+	}
+
+	for _, tt := range tests {
+		if got := telnetColorProfile(tt.termType); got != tt.want {
+			t.Errorf("telnetColorProfile(%q) = %v, want %v", tt.termType, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ThemeFile is the optional per-site color scheme inside a content root
+const ThemeFile = "theme.yaml"
+
+// Theme holds the colors used to render the TUI
+type Theme struct {
+	Accent    string `yaml:"accent"`    // header, sidebar title, prompt title
+	Highlight string `yaml:"highlight"` // active section, pending input
+	Text      string `yaml:"text"`      // inactive sections, controller names
+	Binding   string `yaml:"binding"`   // key bindings
+	Muted     string `yaml:"muted"`     // secondary text
+	Success   string `yaml:"success"`   // confirm actions
+	Danger    string `yaml:"danger"`    // warnings, skip actions
+}
+
+// DefaultTheme returns the built-in color scheme
+func DefaultTheme() Theme {
+	return Theme{
+		Accent:    "#3B82F6",
+		Highlight: "#F59E0B",
+		Text:      "#059669",
+		Binding:   "#7C3AED",
+		Muted:     "#9CA3AF",
+		Success:   "#10B981",
+		Danger:    "#EF4444",
+	}
+}
+
+// loadTheme reads theme.yaml from the content, keeping defaults for unset colors
+func loadTheme(content fs.FS) (Theme, error) {
+	theme := DefaultTheme()
+
+	data, err := fs.ReadFile(content, ThemeFile)
+	if errors.Is(err, fs.ErrNotExist) {
+		return theme, nil
+	}
+	if err != nil {
+		return theme, err
+	}
+
+	if err := yaml.Unmarshal(data, &theme); err != nil {
+		return DefaultTheme(), fmt.Errorf("failed to parse %s: %w", ThemeFile, err)
+	}
+	return theme, nil
+}
@@ -1,7 +1,8 @@
 package main
 
 import (
-	"log"
+	"errors"
+	"fmt"
 	"strings"
 
 	"github.com/charmbracelet/bubbles/textinput"
@@ -10,20 +11,59 @@ import (
 	"github.com/BoburF/terminal-web.git/internal/page"
 )
 
+// ListBullet prefixes <ul> items in the parsed section text
+const ListBullet = "• "
+
 func drawTui(doc *html.Node) (State, error) {
-	boxes, sectionTitles, pageLinks := parseMain(doc)
+	boxes, sectionTitles, pageLinks, err := parseMain(doc)
+	if err != nil {
+		return State{}, err
+	}
+
+	controllers, err := parseControlls(doc)
+	if err != nil {
+		return State{}, err
+	}
+
+	return State{boxes: boxes, interactivity: controllers, sectionTitles: sectionTitles, pageLinks: pageLinks, theme: DefaultTheme()}, nil
+}
+
+// loadEntryState parses the entry page into a TUI state. Lua script
+// problems go to reportScriptError and do not stop the page from loading.
+func loadEntryState(pages *page.Cache, entry string, reportScriptError func(error)) (State, error) {
+	entryPage, err := pages.Load(entry)
+	if err != nil {
+		return State{}, fmt.Errorf("failed to load %s: %w", entry, err)
+	}
+
+	var script *luaScript
+	for node := range entryPage.Doc.Descendants() {
+		if node.Data == "head" {
+			script, err = foundScriptToBind(pages.FS(), node)
+			if err != nil && reportScriptError != nil {
+				reportScriptError(fmt.Errorf("%s: %w", entry, err))
+			}
+		}
 
-	controllers := parseControlls(doc)
+		if node.Data == "body" {
+			state, err := drawTui(node)
+			if err != nil {
+				return State{}, fmt.Errorf("%s: %w", entry, err)
+			}
+			state.script = script
+			return state, nil
+		}
+	}
 
-	return State{boxes: boxes, interactivity: controllers, sectionTitles: sectionTitles, pageLinks: pageLinks}, nil
+	return State{}, fmt.Errorf("%s has no <body>", entry)
 }
 
-func parseControlls(doc *html.Node) []Controller {
+func parseControlls(doc *html.Node) ([]Controller, error) {
 	controllers := make([]Controller, 0)
 
 	controllersSection, err := foundHTMLNodeWithAttr(doc, "div", "class", "controllers")
 	if err != nil {
-		log.Fatalln(err)
+		return nil, errors.New(`page has no <div class="controllers">`)
 	}
 
 	for node := range controllersSection.ChildNodes() {
@@ -39,11 +79,11 @@ func parseControlls(doc *html.Node) []Controller {
 			text = strings.Join(strings.Fields(text), " ")
 			controllType, err := foundAttr(&node.Attr, "type")
 			if err != nil {
-				log.Fatalln(err)
+				return nil, fmt.Errorf("button %q is missing the \"type\" attribute", text)
 			}
 			bindingType, err := foundAttr(&node.Attr, "bind")
 			if err != nil {
-				log.Fatalln(err)
+				return nil, fmt.Errorf("button %q is missing the \"bind\" attribute", text)
 			}
 
 			button.name = text
@@ -54,17 +94,17 @@ func parseControlls(doc *html.Node) []Controller {
 		}
 	}
 
-	return controllers
+	return controllers, nil
 }
 
-func parseMain(doc *html.Node) ([]Box, []string, []page.PageLink) {
+func parseMain(doc *html.Node) ([]Box, []string, []page.PageLink, error) {
 	boxes := make([]Box, 0)
 	sectionTitles := make([]string, 0)
 	pageLinks := make([]page.PageLink, 0)
 
 	main, err := foundHTMLNodeWithAttr(doc, "div", "class", "main")
 	if err != nil {
-		log.Fatalln(err)
+		return nil, nil, nil, errors.New(`page has no <div class="main">`)
 	}
 
 	for node := range main.ChildNodes() {
@@ -115,7 +155,7 @@ func parseMain(doc *html.Node) ([]Box, []string, []page.PageLink) {
 
 					placeholderValue, err := foundAttr(&childNode.Attr, "value")
 					if err != nil {
-						log.Fatalln(err)
+						return nil, nil, nil, fmt.Errorf("input in section %q is missing the \"value\" attribute", sectionTitle)
 					}
 
 					input.Placeholder = placeholderValue.Val
@@ -138,6 +178,27 @@ func parseMain(doc *html.Node) ([]Box, []string, []page.PageLink) {
 						box.texts = append(box.texts, text)
 						box.context = append(box.context, text)
 					}
+				case "ul", "ol":
+					itemNum := 0
+					for item := range childNode.ChildNodes() {
+						if item.Type != html.ElementNode || item.Data != "li" || item.FirstChild == nil {
+							continue
+						}
+						itemNum++
+						text := strings.Join(strings.Fields(nodeText(item)), " ")
+						if childNode.Data == "ol" {
+							text = fmt.Sprintf("%d. %s", itemNum, text)
+						} else {
+							text = ListBullet + text
+						}
+						if box.listItems == nil {
+							box.listItems = make(map[int]string)
+						}
+						box.listItems[len(box.texts)] = childNode.Data
+						box.isNotEmplty = true
+						box.texts = append(box.texts, text)
+						box.context = append(box.context, text)
+					}
 				}
 			default:
 				continue
@@ -147,7 +208,20 @@ func parseMain(doc *html.Node) ([]Box, []string, []page.PageLink) {
 		boxes = append(boxes, box)
 	}
 
-	return boxes, sectionTitles, pageLinks
+	return boxes, sectionTitles, pageLinks, nil
+}
+
+// nodeText joins the text of node and everything inside it, so inline
+// elements like <b> keep their words
+func nodeText(node *html.Node) string {
+	if node.Type == html.TextNode {
+		return node.Data
+	}
+	var text strings.Builder
+	for child := range node.ChildNodes() {
+		text.WriteString(nodeText(child))
+	}
+	return text.String()
 }
 
 func getText(node *html.Node) string {
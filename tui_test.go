@@ -0,0 +1,163 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"golang.org/x/net/html"
+
+	"github.com/BoburF/terminal-web.git/internal/page"
+)
+
+const testControllers = `<div class="controllers"><button type="quit" bind="x">Quit</button></div>`
+
+func TestDrawTuiErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		wantErr string
+	}{
+		{
+			name: "valid page",
+			body: `<div class="main"><div section-title="About"><p>Hi</p><input value="Name"></div></div>` + testControllers,
+		},
+		{
+			name:    "missing main",
+			body:    testControllers,
+			wantErr: `page has no <div class="main">`,
+		},
+		{
+			name:    "missing controllers",
+			body:    `<div class="main"><div section-title="About"><p>Hi</p></div></div>`,
+			wantErr: `page has no <div class="controllers">`,
+		},
+		{
+			name:    "input without value",
+			body:    `<div class="main"><div section-title="Contact"><input></div></div>` + testControllers,
+			wantErr: `input in section "Contact" is missing the "value" attribute`,
+		},
+		{
+			name:    "button without type",
+			body:    `<div class="main"></div><div class="controllers"><button bind="x">Quit</button></div>`,
+			wantErr: `button "Quit" is missing the "type" attribute`,
+		},
+		{
+			name:    "button without bind",
+			body:    `<div class="main"></div><div class="controllers"><button type="quit">Quit</button></div>`,
+			wantErr: `button "Quit" is missing the "bind" attribute`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := html.Parse(strings.NewReader("<body>" + tt.body + "</body>"))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var body *html.Node
+			for node := range doc.Descendants() {
+				if node.Data == "body" {
+					body = node
+					break
+				}
+			}
+
+			_, err = drawTui(body)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("drawTui: %v", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != tt.wantErr {
+				t.Fatalf("drawTui error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoadEntryState(t *testing.T) {
+	entryPage := func(head string) *fstest.MapFile {
+		return &fstest.MapFile{Data: []byte(`<head>` + head + `</head><body><div class="main"><div section-title="About"><p>Hi</p></div></div>` + testControllers + `</body>`)}
+	}
+	script := `<link rel="script" type="lua" href="./index.lua" />`
+
+	tests := []struct {
+		name          string
+		files         fstest.MapFS
+		wantErr       string
+		wantScriptErr string
+		wantSections  int
+	}{
+		{
+			name:         "page without script",
+			files:        fstest.MapFS{"index.html": entryPage("")},
+			wantSections: 1,
+		},
+		{
+			name: "page with script",
+			files: fstest.MapFS{
+				"index.html": entryPage(script),
+				"index.lua":  {Data: []byte(`bind("z", function() quit() end)`)},
+			},
+			wantSections: 1,
+		},
+		{
+			name:    "missing entry",
+			files:   fstest.MapFS{"about.html": entryPage("")},
+			wantErr: "failed to load index.html",
+		},
+		{
+			name:    "broken page",
+			files:   fstest.MapFS{"index.html": {Data: []byte(`<body><p>no main</p></body>`)}},
+			wantErr: `index.html: page has no <div class="main">`,
+		},
+		{
+			name: "script syntax error still loads the page",
+			files: fstest.MapFS{
+				"index.html": entryPage(script),
+				"index.lua":  {Data: []byte(`bind("z", function(`)},
+			},
+			wantScriptErr: "index.html: ",
+			wantSections:  1,
+		},
+		{
+			name:          "missing script still loads the page",
+			files:         fstest.MapFS{"index.html": entryPage(script)},
+			wantScriptErr: "index.html: failed to read script ./index.lua",
+			wantSections:  1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var scriptErr error
+			state, err := loadEntryState(page.NewCache(tt.files), "index.html", func(err error) {
+				scriptErr = errors.Join(scriptErr, err)
+			})
+
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("loadEntryState error = %v, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("loadEntryState: %v", err)
+			}
+			if len(state.boxes) != tt.wantSections {
+				t.Errorf("sections = %d, want %d", len(state.boxes), tt.wantSections)
+			}
+
+			switch {
+			case tt.wantScriptErr == "" && scriptErr != nil:
+				t.Errorf("script error = %v, want none", scriptErr)
+			case tt.wantScriptErr != "" && (scriptErr == nil || !strings.HasPrefix(scriptErr.Error(), tt.wantScriptErr)):
+				t.Errorf("script error = %v, want it to start with %q", scriptErr, tt.wantScriptErr)
+			}
+		})
+	}
+}
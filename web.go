@@ -0,0 +1,242 @@
+package main
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gliderlabs/ssh"
+	"github.com/gorilla/websocket"
+	"github.com/muesli/termenv"
+)
+
+// webIndex is the xterm.js page that connects back to /ws
+//
+//go:embed web/index.html
+var webIndex []byte
+
+// webVendor holds the xterm.js files the page loads from /vendor/
+//
+//go:embed web/vendor
+var webVendor embed.FS
+
+// webVendorFiles are the files web/index.html needs from /vendor/
+var webVendorFiles = []string{"xterm.js", "xterm.css", "addon-fit.js"}
+
+// Terminal size used until a browser or telnet client reports its own
+const (
+	defaultCols = 80
+	defaultRows = 24
+)
+
+var webUpgrader = websocket.Upgrader{
+	ReadBufferSize:  4096,
+	WriteBufferSize: 4096,
+}
+
+// webMessage is sent by the page for keystrokes and terminal resizes
+type webMessage struct {
+	Type string `json:"type"` // "input" or "resize"
+	Data string `json:"data,omitempty"`
+	Cols int    `json:"cols,omitempty"`
+	Rows int    `json:"rows,omitempty"`
+}
+
+// listenHTTP starts the browser terminal on addr alongside the SSH server
+func (s *SSHServer) listenHTTP(addr string) error {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/{$}", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write(webIndex)
+	})
+	mux.HandleFunc("/ws", s.webTerminalHandler)
+
+	vendor, err := fs.Sub(webVendor, "web/vendor")
+	if err != nil {
+		return err
+	}
+	for _, name := range webVendorFiles {
+		if _, err := fs.Stat(vendor, name); err != nil {
+			return fmt.Errorf("web/vendor/%s was not embedded; run 'make web-vendor' and rebuild", name)
+		}
+	}
+	mux.Handle("/vendor/", http.StripPrefix("/vendor/", http.FileServerFS(vendor)))
+
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+
+	server := &http.Server{
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	s.mu.Lock()
+	s.httpServer = server
+	s.mu.Unlock()
+
+	log.Printf("Serving browser terminal on http://%s", listener.Addr())
+	go func() {
+		if err := server.Serve(listener); err != nil && err != http.ErrServerClosed {
+			log.Printf("Browser terminal stopped: %v", err)
+		}
+	}()
+	return nil
+}
+
+// webTerminalHandler bridges a WebSocket to the same TUI SSH visitors get
+func (s *SSHServer) webTerminalHandler(w http.ResponseWriter, r *http.Request) {
+	if !s.beginSession() {
+		http.Error(w, errDraining.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	defer s.handlers.Done()
+
+	conn, err := webUpgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// Upgrade has already replied with an HTTP error
+		return
+	}
+	defer conn.Close()
+
+	remote, err := net.ResolveTCPAddr("tcp", r.RemoteAddr)
+	if err != nil {
+		remote = &net.TCPAddr{}
+	}
+	ip := getClientIP(remote)
+	query := r.URL.Query()
+	startTime := time.Now()
+	config := s.currentConfig()
+	sessionID := generateSessionID(ip, startTime)
+
+	winCh := make(chan ssh.Window, 1)
+	sess := newWebSession(conn, remote, query.Get("open"), winCh)
+
+	// Check rate limit
+	if err := s.admit(ip, config); err != nil {
+		fmt.Fprintf(sess, "%v\r\n", err)
+		return
+	}
+
+	site := s.resolveSite(query.Get("site"))
+
+	activeCount := s.limiter.incrementActive()
+	s.logger.Log(LogEntry{
+		Level:       "INFO",
+		Event:       "SESSION_START",
+		IP:          ip,
+		Username:    "web",
+		SessionID:   sessionID,
+		Site:        site.Name,
+		ActiveConns: activeCount,
+		Message:     fmt.Sprintf("Browser session started (%d/%d active)", activeCount, config.Security.MaxConnections),
+	})
+
+	defer func() {
+		duration := time.Since(startTime)
+		activeCount := s.limiter.decrementActive()
+		s.logger.Log(LogEntry{
+			Level:       "INFO",
+			Event:       "SESSION_END",
+			IP:          ip,
+			SessionID:   sessionID,
+			Site:        site.Name,
+			Duration:    duration.String(),
+			ActiveConns: activeCount,
+			Message:     fmt.Sprintf("Browser session ended. Duration: %v", duration),
+		})
+	}()
+
+	width := queryInt(query.Get("cols"), defaultCols)
+	height := queryInt(query.Get("rows"), defaultRows)
+	s.runTerminal(sess, width, height, termenv.TrueColor, winCh, site, sessionID, "")
+}
+
+// webSession adapts a WebSocket to a terminalSession: input and resize
+// messages arrive as JSON, terminal output is sent as binary frames
+type webSession struct {
+	conn    *websocket.Conn
+	remote  net.Addr
+	command []string
+	input   *io.PipeReader
+	writeMu sync.Mutex
+}
+
+func newWebSession(conn *websocket.Conn, remote net.Addr, open string, winCh chan ssh.Window) *webSession {
+	pr, pw := io.Pipe()
+	sess := &webSession{conn: conn, remote: remote, input: pr}
+	if open != "" {
+		sess.command = []string{open}
+	}
+	go sess.readLoop(pw, winCh)
+	return sess
+}
+
+// readLoop feeds keystrokes into the input pipe and resizes into winCh
+// until the browser disconnects
+func (ws *webSession) readLoop(input *io.PipeWriter, winCh chan ssh.Window) {
+	defer close(winCh)
+	defer input.Close()
+
+	for {
+		_, data, err := ws.conn.ReadMessage()
+		if err != nil {
+			return
+		}
+
+		var msg webMessage
+		if err := json.Unmarshal(data, &msg); err != nil {
+			continue
+		}
+
+		switch msg.Type {
+		case "input":
+			if _, err := input.Write([]byte(msg.Data)); err != nil {
+				return
+			}
+		case "resize":
+			if msg.Cols > 0 && msg.Rows > 0 {
+				sendWindow(winCh, ssh.Window{Width: msg.Cols, Height: msg.Rows})
+			}
+		}
+	}
+}
+
+func (ws *webSession) Read(p []byte) (int, error) {
+	return ws.input.Read(p)
+}
+
+func (ws *webSession) Write(p []byte) (int, error) {
+	ws.writeMu.Lock()
+	defer ws.writeMu.Unlock()
+	if err := ws.conn.WriteMessage(websocket.BinaryMessage, p); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func (ws *webSession) Command() []string {
+	return ws.command
+}
+
+func (ws *webSession) RemoteAddr() net.Addr {
+	return ws.remote
+}
+
+// queryInt parses a positive query parameter, falling back to def
+func queryInt(value string, def int) int {
+	n, err := strconv.Atoi(value)
+	if err != nil || n <= 0 {
+		return def
+	}
+	return n
+}